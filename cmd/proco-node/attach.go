@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"proco-node/node"
+	"proco-node/rpc"
+)
+
+// runAttach implements `proco-node attach [--json] <rpc-url>`: the node's
+// console, run against a live node through its RPC API. Besides the node's
+// own commands there is `rpc <method> [params...]` for raw calls; params
+// that parse as JSON are sent as such, anything else as a string.
+func runAttach(args []string) {
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print results as JSON values, for scripting")
+	fs.Parse(args)
+	url := "http://" + rpc.DefaultAddr
+	if fs.NArg() > 0 {
+		url = fs.Arg(0)
+	}
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	a := &attachSession{client: rpc.Dial(url), json: *asJSON}
+	var genesis map[string]string
+	if err := a.client.Call(&genesis, "chain_getGenesis"); err != nil {
+		fatal(fmt.Errorf("cannot reach %s: %w", url, err))
+	}
+	if err := a.client.Call(&a.commands, "console_commands"); err != nil {
+		fatal(err)
+	}
+	a.client.Call(&a.methods, "rpc_methods")
+	if !a.json {
+		fmt.Printf("🔌 Attached to %s (chain %s, genesis %s)\n", url, genesis["chain_id"], genesis["hash"])
+		fmt.Println("Type 'help' for commands, 'exit' to leave.")
+	}
+
+	histFile := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		histFile = filepath.Join(home, ".proco_history")
+	}
+	ed := newLineEditor(histFile, a.complete)
+	defer ed.Close()
+
+	prompt := "> "
+	if a.json {
+		prompt = ""
+	}
+	for {
+		line, err := ed.ReadLine(prompt)
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return
+		}
+		line = strings.TrimSpace(line)
+		if line == "exit" {
+			return
+		}
+		if line != "" {
+			a.exec(line)
+		}
+	}
+}
+
+type attachSession struct {
+	client   *rpc.Client
+	json     bool
+	commands []node.Command
+	methods  []string
+}
+
+func (a *attachSession) exec(line string) {
+	var result node.CommandResult
+	var err error
+	if fields := strings.Fields(line); fields[0] == "rpc" {
+		err = a.rawCall(fields[1:], &result)
+	} else {
+		err = a.client.Call(&result, "console_exec", line)
+	}
+
+	if a.json {
+		out := map[string]interface{}{"value": result.Value}
+		if err != nil {
+			out = map[string]interface{}{"error": err.Error()}
+		}
+		b, _ := json.Marshal(out)
+		fmt.Println(string(b))
+		return
+	}
+	if err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if result.Text != "" {
+		fmt.Println(result.Text)
+	}
+}
+
+// rawCall runs `rpc <method> [params...]`.
+func (a *attachSession) rawCall(args []string, result *node.CommandResult) error {
+	if len(args) == 0 {
+		return errors.New("usage: rpc <method> [params...]")
+	}
+	params := make([]interface{}, len(args)-1)
+	for i, f := range args[1:] {
+		var v interface{}
+		if json.Unmarshal([]byte(f), &v) == nil {
+			params[i] = json.RawMessage(f)
+		} else {
+			params[i] = f
+		}
+	}
+	var raw json.RawMessage
+	if err := a.client.Call(&raw, args[0], params...); err != nil {
+		return err
+	}
+	json.Unmarshal(raw, &result.Value)
+	out, _ := json.MarshalIndent(result.Value, "", "  ")
+	result.Text = string(out)
+	return nil
+}
+
+// complete offers command names for the first word, RPC method names after
+// `rpc`, and wallet addresses of the node for later words.
+func (a *attachSession) complete(line string) []string {
+	fields := strings.Fields(line)
+	word := ""
+	if !strings.HasSuffix(line, " ") && len(fields) > 0 {
+		word, fields = fields[len(fields)-1], fields[:len(fields)-1]
+	}
+
+	var pool []string
+	switch {
+	case len(fields) == 0:
+		pool = append(pool, "exit", "rpc")
+		for _, c := range a.commands {
+			pool = append(pool, c.Name)
+		}
+	case fields[0] == "rpc" && len(fields) == 1:
+		pool = a.methods
+	default:
+		var wallets node.CommandResult
+		if a.client.Call(&wallets, "console_exec", "list_wallets") == nil {
+			if list, ok := wallets.Value.([]interface{}); ok {
+				for _, w := range list {
+					if m, ok := w.(map[string]interface{}); ok {
+						if addr, ok := m["address"].(string); ok {
+							pool = append(pool, addr)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	var out []string
+	for _, p := range pool {
+		if strings.HasPrefix(p, word) {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"proco-node/node"
+)
+
+const chainUsage = `Usage: proco-node chain <show|validate|verify|genesis|reindex> [flags]`
+
+// runChain implements `proco-node chain`. It reads the chain file of a
+// data directory and needs no running node.
+func runChain(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, chainUsage)
+		os.Exit(2)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("chain "+sub, flag.ExitOnError)
+	f := addNodeFlags(fs)
+	full := fs.Bool("full", false, "verify: report every failure instead of stopping at the first")
+	asJSON := fs.Bool("json", false, "verify: print the report as JSON")
+	fs.Parse(args[1:])
+
+	cfg := f.nodeConfig()
+	spec, err := cfg.LoadGenesis()
+	if err != nil {
+		fatal(err)
+	}
+	bc, err := node.LoadBlockchain(cfg.Path(node.ChainFile), spec)
+	if err != nil {
+		fatal(err)
+	}
+
+	switch sub {
+	case "show":
+		bc.ShowChain()
+	case "validate":
+		bc.ValidateChain(os.Stdout)
+	case "verify":
+		report := bc.VerifyChain(*full)
+		if *asJSON {
+			report.WriteJSON(os.Stdout)
+		} else {
+			report.WriteText(os.Stdout)
+		}
+		if !report.Valid {
+			os.Exit(1)
+		}
+	case "genesis":
+		fmt.Println("Chain ID    :", spec.ChainID)
+		fmt.Println("Genesis hash:", bc.GenesisHash())
+		fmt.Println("Height      :", bc.Height())
+	case "reindex":
+		if cfg.Indexes != nil {
+			if err := bc.SetIndexes(cfg.Indexes); err != nil {
+				fatal(err)
+			}
+		}
+		n, err := bc.Reindex()
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("Indexed %d blocks (%s)\n", n, strings.Join(bc.Indexes(), ", "))
+	default:
+		fmt.Fprintln(os.Stderr, chainUsage)
+		os.Exit(2)
+	}
+}
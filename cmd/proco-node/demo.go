@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"proco-node/node"
+)
+
+const demoUsage = `Usage: proco-node demo tamper [flags]`
+
+// runDemo implements `proco-node demo`. The tamper demo works on a copy of
+// the chain in <datadir> inside a sandbox directory, so the data directory
+// itself is never modified.
+func runDemo(args []string) {
+	if len(args) == 0 || args[0] != "tamper" {
+		fmt.Fprintln(os.Stderr, demoUsage)
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("demo tamper", flag.ExitOnError)
+	f := addNodeFlags(fs)
+	fs.Set("log-level", "warn") // keep the narration readable; -log-level overrides
+	block := fs.Int("block", 0, "height of the block to modify (default: one in the middle)")
+	mode := fs.String("mode", "", "what to modify: tx (a signed amount), data (the block data) or rehash (the data, then recompute the hash)")
+	sandbox := fs.String("sandbox", "", "directory for the copies (default: a temporary directory, removed afterwards)")
+	pause := fs.Bool("pause", false, "wait for Enter between steps")
+	fs.Parse(args[1:])
+
+	cfg := f.nodeConfig()
+	spec, err := cfg.LoadGenesis()
+	if err != nil {
+		fatal(err)
+	}
+	dir := *sandbox
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "proco-tamper-"); err != nil {
+			fatal(err)
+		}
+		defer os.RemoveAll(dir)
+	}
+
+	demo := node.TamperDemo{
+		Spec:   spec,
+		Source: cfg.Path(node.ChainFile),
+		Dir:    dir,
+		Block:  *block,
+		Mode:   *mode,
+	}
+	if *pause {
+		stdin := bufio.NewReader(os.Stdin)
+		demo.Pause = func() {
+			fmt.Print("\n[press Enter to continue]")
+			stdin.ReadString('\n')
+		}
+	}
+	if err := node.RunTamperDemo(demo, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "❌", err)
+		if *sandbox == "" {
+			os.RemoveAll(dir)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"proco-node/node"
+)
+
+// runInit implements `proco-node init`: it writes a genesis spec and one
+// ready-to-run data directory per validator.
+func runInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", "testnet", "output directory")
+	chainID := fs.String("chain-id", "proco-testnet", "chain ID of the new network")
+	validators := fs.Int("validators", 3, "number of validator nodes")
+	alloc := fs.String("alloc", "", "genesis balances as name-or-address=amount,... (names are node1, node2, ...)")
+	host := fs.String("host", "127.0.0.1", "interface the nodes listen on")
+	p2pPort := fs.Int("p2p-port", 3001, "P2P port of node1, the others count up")
+	rpcPort := fs.Int("rpc-port", 8545, "RPC port of node1, the others count up")
+	minFee := fs.Int("min-fee", 1, "minimum transaction fee")
+	fs.Parse(args)
+
+	balances, err := parseAlloc(*alloc)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	spec, err := node.InitNetwork(node.InitOptions{
+		Dir:        *dir,
+		ChainID:    *chainID,
+		Validators: *validators,
+		Alloc:      balances,
+		Host:       *host,
+		P2PPort:    *p2pPort,
+		RPCPort:    *rpcPort,
+		MinTxFee:   *minFee,
+	})
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Network %s written to %s\n", spec.ChainID, *dir)
+	fmt.Println("Genesis hash:", node.NewGenesisBlock(spec).Hash)
+	for _, v := range spec.Validators {
+		fmt.Printf("  %s  validator %s\n", v.Name, v.Address)
+	}
+	fmt.Println("Start each node with:")
+	for _, v := range spec.Validators {
+		fmt.Printf("  proco-node run --datadir %s\n", filepath.Join(*dir, v.Name))
+	}
+}
+
+// parseAlloc parses "node1=500,abc...=100".
+func parseAlloc(s string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		who, amount, ok := strings.Cut(part, "=")
+		n, err := strconv.Atoi(amount)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("bad allocation %q, want name=amount", part)
+		}
+		out[who] += n
+	}
+	return out, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ---------------- LINE EDITOR ----------------
+// lineEditor reads console lines with history (up/down arrows, kept in a
+// file between sessions) and tab completion. It puts the terminal in
+// non-canonical mode with stty; when stdin is not a terminal, or stty is
+// missing, it falls back to reading plain lines.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  []string
+	histFile string
+	complete func(line string) []string // candidates for the last word of line
+	restore  string                     // stty settings to restore, "" when not raw
+}
+
+const maxHistory = 1000
+
+// errInterrupted is returned for Ctrl-C; the caller starts a fresh line.
+var errInterrupted = errors.New("interrupted")
+
+func newLineEditor(histFile string, complete func(string) []string) *lineEditor {
+	e := &lineEditor{in: bufio.NewReader(os.Stdin), out: os.Stdout, histFile: histFile, complete: complete}
+	if data, err := os.ReadFile(histFile); err == nil {
+		for _, l := range strings.Split(string(data), "\n") {
+			if l != "" {
+				e.history = append(e.history, l)
+			}
+		}
+	}
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		if saved, err := stty("-g"); err == nil {
+			if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err == nil {
+				e.restore = strings.TrimSpace(saved)
+			}
+		}
+	}
+	return e
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Close restores the terminal and saves the history.
+func (e *lineEditor) Close() {
+	if e.restore != "" {
+		stty(e.restore)
+	}
+	if e.histFile != "" {
+		h := e.history
+		if len(h) > maxHistory {
+			h = h[len(h)-maxHistory:]
+		}
+		os.WriteFile(e.histFile, []byte(strings.Join(h, "\n")+"\n"), 0o600)
+	}
+}
+
+// ReadLine shows prompt and returns the next line without its newline.
+// It returns io.EOF at the end of input or on Ctrl-D at an empty line.
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	var line string
+	var err error
+	if e.restore == "" {
+		line, err = e.in.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+	} else {
+		line, err = e.edit(prompt)
+		fmt.Fprint(e.out, "\r\n")
+		if err != nil {
+			return "", err
+		}
+	}
+	if t := strings.TrimSpace(line); t != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != t) {
+		e.history = append(e.history, t)
+	}
+	return line, nil
+}
+
+// edit runs the key loop of one line in non-canonical mode.
+func (e *lineEditor) edit(prompt string) (string, error) {
+	var buf []rune
+	pos := 0
+	hist := len(e.history) // index into history; len means the new line
+	var draft []rune       // the new line while browsing history
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	recall := func(i int) {
+		if i < 0 || i > len(e.history) {
+			return
+		}
+		if hist == len(e.history) {
+			draft = buf
+		}
+		hist = i
+		if i == len(e.history) {
+			buf = draft
+		} else {
+			buf = []rune(e.history[i])
+		}
+		pos = len(buf)
+		redraw()
+	}
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			return string(buf), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				return "", io.EOF
+			}
+		case 1: // Ctrl-A
+			pos = 0
+			redraw()
+		case 5: // Ctrl-E
+			pos = len(buf)
+			redraw()
+		case 21: // Ctrl-U
+			buf, pos = buf[pos:], 0
+			redraw()
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+				redraw()
+			}
+		case '\t':
+			buf, pos = e.completeAt(buf, pos, prompt)
+			redraw()
+		case 27: // escape sequence: arrows
+			if b, _ := e.in.ReadByte(); b != '[' {
+				continue
+			}
+			switch b, _ := e.in.ReadByte(); b {
+			case 'A':
+				recall(hist - 1)
+			case 'B':
+				recall(hist + 1)
+			case 'C':
+				if pos < len(buf) {
+					pos++
+					redraw()
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			}
+		default:
+			if r >= 32 {
+				buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+				pos++
+				redraw()
+			}
+		}
+	}
+}
+
+// completeAt completes the word before the cursor. One candidate is
+// inserted whole; several are listed and their common prefix inserted.
+func (e *lineEditor) completeAt(buf []rune, pos int, prompt string) ([]rune, int) {
+	if e.complete == nil {
+		return buf, pos
+	}
+	before := string(buf[:pos])
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+	cands := e.complete(before)
+	if len(cands) == 0 {
+		return buf, pos
+	}
+	fill := commonPrefix(cands)
+	if len(cands) == 1 {
+		fill += " "
+	} else {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(cands, "  "))
+	}
+	if !strings.HasPrefix(fill, word) {
+		return buf, pos
+	}
+	insert := []rune(fill[len(word):])
+	out := append(append(append([]rune{}, buf[:pos]...), insert...), buf[pos:]...)
+	return out, pos + len(insert)
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -1,7 +1,173 @@
 package main
 
-import "proco-node/node"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"proco-node/node"
+)
+
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+const usage = `Usage: proco-node <command> [flags]
+
+Commands:
+  run         start a node (the default)
+  init        generate validator keys, a genesis file and node data directories
+  wallet      manage the wallets of a data directory (new, list, balance)
+  chain       inspect the chain of a data directory (show, validate, verify, genesis, reindex)
+  attach      open a console on a running node over RPC
+  run-script  run console commands from a file, exiting non-zero on a failure
+  testnet     run a local network of N nodes with a control prompt
+  demo        classroom demos (tamper: edit a block in a sandbox and watch the network refuse it)
+  version     print the version
+
+Run 'proco-node <command> -h' for the flags of a command.
+`
 
 func main() {
-	node.StartNode()
+	args := os.Args[1:]
+	cmd := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "run":
+		runNode(args)
+	case "init":
+		runInit(args)
+	case "wallet":
+		runWallet(args)
+	case "chain":
+		runChain(args)
+	case "attach":
+		runAttach(args)
+	case "run-script":
+		runScript(args)
+	case "testnet":
+		runTestnet(args)
+	case "demo":
+		runDemo(args)
+	case "version":
+		fmt.Println("proco-node", version)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+}
+
+// ---------------- COMMON FLAGS ----------------
+// nodeFlags are shared by every command that opens a data directory.
+type nodeFlags struct {
+	datadir   string
+	config    string
+	p2pAddr   string
+	rpcAddr   string
+	peers     string
+	logLevel  string
+	logFormat string
+}
+
+func addNodeFlags(fs *flag.FlagSet) *nodeFlags {
+	f := &nodeFlags{}
+	fs.StringVar(&f.datadir, "datadir", ".", "data directory of the node")
+	fs.StringVar(&f.config, "config", "", "genesis spec (default: the one named in <datadir>/node.json)")
+	fs.StringVar(&f.p2pAddr, "p2p-addr", "", "P2P listen address (default: from node.json)")
+	fs.StringVar(&f.rpcAddr, "rpc-addr", "", "RPC listen address (default: from node.json)")
+	fs.StringVar(&f.peers, "peers", "", "comma-separated peer addresses, replacing those in node.json")
+	fs.StringVar(&f.logLevel, "log-level", "info", "debug, info, warn or error, optionally with per-subsystem levels: info,p2p=debug")
+	fs.StringVar(&f.logFormat, "log-format", "text", "text or json")
+	return f
+}
+
+// nodeConfig loads node.json from the data directory and applies the
+// flags on top of it.
+func (f *nodeFlags) nodeConfig() *node.NodeConfig {
+	level, levels, err := node.ParseLogLevels(f.logLevel)
+	if err != nil {
+		fatal(err)
+	}
+	if f.logFormat != "text" && f.logFormat != "json" {
+		fatal(fmt.Errorf("bad log format %q, want text or json", f.logFormat))
+	}
+	node.SetupLogging(node.LogConfig{Level: level, Levels: levels, JSON: f.logFormat == "json"})
+
+	cfg, err := node.LoadNodeConfig(f.datadir)
+	if err != nil {
+		fatal(err)
+	}
+	if f.config != "" {
+		// Relative to the working directory, not the data directory.
+		if cfg.Genesis, err = filepath.Abs(f.config); err != nil {
+			fatal(err)
+		}
+	}
+	if f.p2pAddr != "" {
+		cfg.P2PAddr = f.p2pAddr
+	}
+	if f.rpcAddr != "" {
+		cfg.RPCAddr = f.rpcAddr
+	}
+	if f.peers != "" {
+		cfg.Peers = nil
+		for _, p := range strings.Split(f.peers, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.Peers = append(cfg.Peers, p)
+			}
+		}
+	}
+	return cfg
+}
+
+// clockFlags record the time a node reads, or replay a recording, so a
+// run can be repeated with the same timestamps and hashes.
+type clockFlags struct {
+	record string
+	replay string
+}
+
+func addClockFlags(fs *flag.FlagSet) *clockFlags {
+	f := &clockFlags{}
+	fs.StringVar(&f.record, "record-clock", "", "write every clock reading to this file")
+	fs.StringVar(&f.replay, "replay-clock", "", "read the clock from a file written by -record-clock")
+	return f
+}
+
+// apply sets the clock of n. The returned function closes the recording.
+func (f *clockFlags) apply(n *node.Node) func() {
+	switch {
+	case f.record != "" && f.replay != "":
+		fatal(fmt.Errorf("-record-clock and -replay-clock do not go together"))
+	case f.record != "":
+		file, err := os.Create(f.record)
+		if err != nil {
+			fatal(err)
+		}
+		n.Chain.SetClock(node.RecordClock(node.SystemClock{}, file))
+		return func() { file.Close() }
+	case f.replay != "":
+		file, err := os.Open(f.replay)
+		if err != nil {
+			fatal(err)
+		}
+		defer file.Close()
+		clock, err := node.NewReplayClock(file)
+		if err != nil {
+			fatal(err)
+		}
+		n.Chain.SetClock(clock)
+	}
+	return func() {}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
 }
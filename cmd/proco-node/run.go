@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"proco-node/node"
+	"proco-node/rpc"
+)
+
+// runNode implements `proco-node run`.
+func runNode(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	f := addNodeFlags(fs)
+	daemon := fs.Bool("daemon", false, "run without the console until interrupted")
+	cf := addClockFlags(fs)
+	fs.Parse(args)
+
+	cfg := f.nodeConfig()
+	n, err := node.OpenNode(cfg)
+	if err != nil {
+		fatal(err)
+	}
+	defer cf.apply(n)()
+	slog.Info("node opened", "chain_id", n.Spec.ChainID, "genesis", n.Chain.GenesisHash(), "datadir", cfg.DataDir)
+
+	rpcAddr := cfg.RPCAddr
+	if rpcAddr == "" {
+		rpcAddr = rpc.DefaultAddr
+	}
+	n.Network.SetRPCAddr(rpcAddr)
+	go func() {
+		if err := rpc.NewServer(n.Chain, n.Mempool, n.Network).ListenAndServe(rpcAddr); err != nil {
+			node.Logger(node.LogRPC).Error("server stopped", "err", err)
+		}
+	}()
+
+	if err := n.Start(); err != nil {
+		fatal(fmt.Errorf("starting p2p: %w", err))
+	}
+	defer n.Stop()
+
+	if *daemon {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		s := <-sig
+		slog.Info("shutting down", "signal", s.String())
+		return
+	}
+	node.StartNode(n.Chain, n.Mempool, n.Network)
+}
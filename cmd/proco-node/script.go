@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"proco-node/node"
+)
+
+// runScript implements `proco-node run-script <file>`. Without --datadir
+// the script runs on a fresh chain in a temporary directory, so it gives
+// the same result every time.
+func runScript(args []string) {
+	fs := flag.NewFlagSet("run-script", flag.ExitOnError)
+	f := addNodeFlags(fs)
+	cf := addClockFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: proco-node run-script [flags] <file>")
+		os.Exit(2)
+	}
+	script, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	defer script.Close()
+
+	sandbox := true
+	fs.Visit(func(fl *flag.Flag) { sandbox = sandbox && fl.Name != "datadir" })
+	if sandbox {
+		tmp, err := os.MkdirTemp("", "proco-script-")
+		if err != nil {
+			fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+		f.datadir = tmp
+	}
+	n, err := node.OpenNode(f.nodeConfig())
+	if err != nil {
+		fatal(err)
+	}
+
+	closeClock := cf.apply(n)
+	console := node.NewConsole(n.Chain, n.Mempool, nil)
+	if err := node.RunScript(console, script, os.Stdout); err != nil {
+		closeClock()
+		fmt.Fprintln(os.Stderr, "❌", err)
+		if sandbox {
+			os.RemoveAll(f.datadir)
+		}
+		os.Exit(1)
+	}
+	closeClock()
+	fmt.Println("✅ Script passed")
+}
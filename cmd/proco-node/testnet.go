@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"proco-node/testnet"
+)
+
+const testnetHelp = `Testnet commands:
+  status                         height, head and partition of every node
+  stop <node>                    stop a node, keeping its data
+  start <node>                   start a stopped node
+  restart <node>                 stop and start a node
+  partition <n,n,...> [<n,...>]  split the network; unnamed nodes form one more group
+  heal                           remove the partition
+  fault <node|all> [list]        show the fault rules of a node
+  fault <node|all> add <action> [peer=<node>] [type=<TYPE>] [prob=<0..1>] [ms=<n>]
+                                 make a node's outgoing messages misbehave; actions:
+                                 delay, drop, duplicate, reorder (ms), corrupt
+  fault <node|all> remove <id>   remove a fault rule
+  fault <node|all> clear         remove every fault rule
+  logs [node...] [-n lines]      show the latest log lines (default 50)
+  follow on|off|<node...>        stream new log lines, of all or some nodes
+  exec <node> <command...>       run a console command on a node, e.g. exec 1 add_block hello
+  help                           show this help
+  quit                           stop every node and exit
+Nodes can be named node3 or just 3.`
+
+// runTestnet implements `proco-node testnet`: a local network of full nodes
+// on loopback ports with one shared genesis, driven from a control prompt.
+func runTestnet(args []string) {
+	fs := flag.NewFlagSet("testnet", flag.ExitOnError)
+	nodes := fs.Int("nodes", 5, "number of nodes")
+	dir := fs.String("dir", "", "network directory, reused if it holds one (default: a temporary directory, removed afterwards)")
+	processes := fs.Bool("processes", false, "run every node as a child process instead of in this process")
+	chainID := fs.String("chain-id", "proco-local", "chain ID of a new network")
+	alloc := fs.String("alloc", "", "genesis balances as name-or-address=amount,... (default: 1000000 to every node)")
+	p2pPort := fs.Int("p2p-port", 4001, "P2P port of node1, the others count up")
+	rpcPort := fs.Int("rpc-port", 9545, "RPC port of node1, the others count up")
+	logLevel := fs.String("log-level", "info", "log level of the nodes, optionally with per-subsystem levels: info,p2p=debug")
+	fs.Parse(args)
+
+	balances, err := parseAlloc(*alloc)
+	if err != nil {
+		fatal(err)
+	}
+	if len(balances) == 0 {
+		for i := 1; i <= *nodes; i++ {
+			balances[fmt.Sprintf("node%d", i)] = 1000000
+		}
+	}
+	if *dir == "" {
+		tmp, err := os.MkdirTemp("", "proco-testnet-")
+		if err != nil {
+			fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+		*dir = filepath.Join(tmp, "net")
+	}
+
+	tn, err := testnet.New(testnet.Options{
+		Dir:       *dir,
+		Nodes:     *nodes,
+		ChainID:   *chainID,
+		Alloc:     balances,
+		P2PPort:   *p2pPort,
+		RPCPort:   *rpcPort,
+		Processes: *processes,
+		LogLevel:  *logLevel,
+	})
+	if err != nil {
+		fatal(err)
+	}
+	defer tn.Close()
+	tn.Logs.Follow(os.Stdout)
+	if err := tn.Start(); err != nil {
+		tn.Close()
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return
+	}
+
+	mode := "in this process"
+	if *processes {
+		mode = "as child processes"
+	}
+	fmt.Printf("🌐 Testnet %s: %d nodes running %s, data in %s\n", tn.Spec.ChainID, len(tn.Names()), mode, *dir)
+	printStatus(tn)
+	fmt.Println("Type 'help' for commands, 'quit' to stop the network.")
+
+	ed := newLineEditor("", testnetCompleter(tn))
+	defer ed.Close()
+	for {
+		line, err := ed.ReadLine("testnet> ")
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return
+		}
+		if err := testnetCommand(tn, fields); err != nil {
+			fmt.Println("❌", err)
+		}
+	}
+}
+
+func testnetCommand(tn *testnet.Testnet, fields []string) error {
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "status":
+		printStatus(tn)
+	case "stop", "start", "restart":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s <node>", cmd)
+		}
+		action := map[string]func(string) error{"stop": tn.StopNode, "start": tn.StartNode, "restart": tn.RestartNode}[cmd]
+		return action(args[0])
+	case "partition":
+		if len(args) == 0 {
+			return errors.New("usage: partition <n,n,...> [<n,...>]")
+		}
+		groups := make([][]string, len(args))
+		for i, a := range args {
+			groups[i] = strings.Split(a, ",")
+		}
+		if err := tn.Partition(groups); err != nil {
+			return err
+		}
+		printStatus(tn)
+	case "heal":
+		return tn.Heal()
+	case "fault":
+		if len(args) == 0 {
+			return errors.New("usage: fault <node|all> [list | add ... | remove <id> | clear]")
+		}
+		targets := args[:1]
+		if args[0] == "all" {
+			targets = tn.Names()
+		}
+		for _, name := range targets {
+			res, err := tn.Fault(name, args[1:])
+			if err != nil {
+				fmt.Printf("%s: ❌ %v\n", name, err)
+				continue
+			}
+			fmt.Printf("%s: %s\n", name, res.Text)
+		}
+	case "logs":
+		n := 50
+		if len(args) >= 2 && args[len(args)-2] == "-n" {
+			v, err := strconv.Atoi(args[len(args)-1])
+			if err != nil {
+				return fmt.Errorf("bad line count %q", args[len(args)-1])
+			}
+			n, args = v, args[:len(args)-2]
+		}
+		names, err := resolveAll(tn, args)
+		if err != nil {
+			return err
+		}
+		for _, l := range tn.Logs.Tail(n, names...) {
+			fmt.Println(l)
+		}
+	case "follow":
+		switch {
+		case len(args) == 1 && args[0] == "off":
+			tn.Logs.Follow(nil)
+		case len(args) == 1 && args[0] == "on", len(args) == 0:
+			tn.Logs.Follow(os.Stdout)
+		default:
+			names, err := resolveAll(tn, args)
+			if err != nil {
+				return err
+			}
+			tn.Logs.Follow(os.Stdout, names...)
+		}
+	case "exec":
+		if len(args) < 2 {
+			return errors.New("usage: exec <node> <command...>")
+		}
+		res, err := tn.Exec(args[0], strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		if res.Text != "" {
+			fmt.Println(res.Text)
+		}
+	case "help":
+		fmt.Println(testnetHelp)
+	default:
+		return fmt.Errorf("unknown command %q, try 'help'", cmd)
+	}
+	return nil
+}
+
+func resolveAll(tn *testnet.Testnet, refs []string) ([]string, error) {
+	names := make([]string, len(refs))
+	for i, r := range refs {
+		name, err := tn.Resolve(r)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+func printStatus(tn *testnet.Testnet) {
+	fmt.Printf("%-7s %-8s %-16s %-16s %6s  %-12s %s\n", "NODE", "STATE", "P2P", "RPC", "HEIGHT", "HEAD", "CUT FROM")
+	for _, s := range tn.Status() {
+		state, head := "stopped", ""
+		if s.Running {
+			state = "running"
+		}
+		if len(s.Head) >= 12 {
+			head = s.Head[:12]
+		}
+		fmt.Printf("%-7s %-8s %-16s %-16s %6d  %-12s %s\n", s.Name, state, s.P2PAddr, s.RPCAddr, s.Height, head, strings.Join(s.Cut, ","))
+	}
+}
+
+// testnetCompleter offers command names for the first word and node names
+// after it.
+func testnetCompleter(tn *testnet.Testnet) func(string) []string {
+	commands := []string{"status", "stop", "start", "restart", "partition", "heal", "fault", "logs", "follow", "exec", "help", "quit"}
+	return func(line string) []string {
+		fields := strings.Fields(line)
+		word := ""
+		if !strings.HasSuffix(line, " ") && len(fields) > 0 {
+			word, fields = fields[len(fields)-1], fields[:len(fields)-1]
+		}
+		pool := commands
+		if len(fields) > 0 {
+			pool = tn.Names()
+		}
+		var out []string
+		for _, p := range pool {
+			if strings.HasPrefix(p, word) {
+				out = append(out, p)
+			}
+		}
+		sort.Strings(out)
+		return out
+	}
+}
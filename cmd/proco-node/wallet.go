@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"proco-node/node"
+)
+
+const walletUsage = `Usage: proco-node wallet <new|list|balance <address>> [flags]`
+
+// runWallet implements `proco-node wallet`. It works on the files of a
+// data directory and needs no running node.
+func runWallet(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, walletUsage)
+		os.Exit(2)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("wallet "+sub, flag.ExitOnError)
+	f := addNodeFlags(fs)
+	fs.Parse(args[1:])
+
+	n, err := node.OpenNode(f.nodeConfig())
+	if err != nil {
+		fatal(err)
+	}
+	bc := n.Chain
+
+	switch sub {
+	case "new":
+		w, err := node.GenerateWallet()
+		if err != nil {
+			fatal(err)
+		}
+		if err := bc.AddWallet(w); err != nil {
+			fatal(err)
+		}
+		fmt.Println(w.Address)
+	case "list":
+		for _, w := range bc.LocalWallets() {
+			fmt.Printf("%s  %d\n", w.Address, node.GetBalance(bc, w.Address))
+		}
+	case "balance":
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, walletUsage)
+			os.Exit(2)
+		}
+		fmt.Println(node.GetBalance(bc, fs.Arg(0)))
+	default:
+		fmt.Fprintln(os.Stderr, walletUsage)
+		os.Exit(2)
+	}
+}
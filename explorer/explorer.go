@@ -0,0 +1,175 @@
+// Package explorer is a read-only block explorer served by the node. It
+// reads the chain through the same lookups as the RPC API.
+package explorer
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"proco-node/node"
+)
+
+// Path is where the explorer is mounted.
+const Path = "/explorer/"
+
+// LatestBlocks is the number of blocks on the front page.
+const LatestBlocks = 20
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var funcs = template.FuncMap{
+	"short": func(s string) string {
+		if len(s) > 16 {
+			return s[:16] + "…"
+		}
+		return s
+	},
+}
+
+var pages = map[string]*template.Template{}
+
+func init() {
+	for _, name := range []string{"index", "block", "tx", "address", "notfound"} {
+		pages[name] = template.Must(template.New("layout.html").Funcs(funcs).
+			ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html"))
+	}
+}
+
+// Explorer serves the explorer pages of one node.
+type Explorer struct {
+	bc *node.Blockchain
+	mp *node.Mempool
+}
+
+func New(bc *node.Blockchain, mp *node.Mempool) *Explorer {
+	return &Explorer{bc: bc, mp: mp}
+}
+
+// ServeHTTP routes /explorer/, /explorer/block/<height or hash>,
+// /explorer/tx/<hash>, /explorer/address/<address> and
+// /explorer/search?q=<anything>.
+func (e *Explorer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	page, arg, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, Path), "/")
+	switch page {
+	case "":
+		e.index(w)
+	case "block":
+		e.block(w, arg)
+	case "tx":
+		e.tx(w, arg)
+	case "address":
+		e.address(w, arg, r.URL.Query().Get("cursor"))
+	case "search":
+		e.search(w, r, strings.TrimSpace(r.URL.Query().Get("q")))
+	default:
+		e.notFound(w, r.URL.Path)
+	}
+}
+
+func (e *Explorer) render(w http.ResponseWriter, status int, page string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pages[page].Execute(w, data); err != nil {
+		node.Logger(node.LogRPC).Error("rendering explorer page failed", "page", page, "err", err)
+	}
+}
+
+func (e *Explorer) notFound(w http.ResponseWriter, what string) {
+	e.render(w, http.StatusNotFound, "notfound", map[string]string{"What": what})
+}
+
+// ---------------- PAGES ----------------
+func (e *Explorer) index(w http.ResponseWriter) {
+	cfg := e.bc.Config()
+	e.render(w, http.StatusOK, "index", map[string]interface{}{
+		"ChainID": cfg.ChainID,
+		"Genesis": e.bc.GenesisHash(),
+		"Height":  e.bc.Height(),
+		"Pending": len(e.mp.Pending()),
+		"Future":  len(e.mp.Future()),
+		"Blocks":  e.bc.LatestBlocks(LatestBlocks),
+	})
+}
+
+func (e *Explorer) block(w http.ResponseWriter, id string) {
+	block, err := e.bc.FindBlock(id)
+	if err != nil {
+		e.notFound(w, "block "+id)
+		return
+	}
+	txs := make([]node.TxLocation, len(block.Txs))
+	for i, tx := range block.Txs {
+		txs[i] = node.TxLocation{Tx: tx, Hash: tx.Hash(), Height: block.Index, BlockHash: block.Hash, Index: i}
+	}
+	e.render(w, http.StatusOK, "block", map[string]interface{}{
+		"Block": block,
+		"Txs":   txs,
+		"Head":  e.bc.Height(),
+		"Next":  block.Index + 1,
+	})
+}
+
+func (e *Explorer) tx(w http.ResponseWriter, hash string) {
+	loc, err := e.bc.TxByHash(hash)
+	if err != nil {
+		e.notFound(w, "transaction "+hash)
+		return
+	}
+	e.render(w, http.StatusOK, "tx", map[string]interface{}{
+		"Loc":           loc,
+		"Confirmations": e.bc.Height() - loc.Height + 1,
+	})
+}
+
+func (e *Explorer) address(w http.ResponseWriter, address, cursor string) {
+	if !node.IsAddress(address) {
+		e.notFound(w, "address "+address)
+		return
+	}
+	history, err := e.bc.AddressHistory(address, cursor, node.DefaultPageSize)
+	if err != nil {
+		e.notFound(w, "page "+cursor+" of address "+address)
+		return
+	}
+	acc := node.Account{}
+	if a := e.bc.State().Get(address); a != nil {
+		acc = *a
+	}
+	var pending []node.Transaction
+	for _, tx := range append(e.mp.Pending(), e.mp.Future()...) {
+		if tx.From == address || tx.To == address {
+			pending = append(pending, tx)
+		}
+	}
+	e.render(w, http.StatusOK, "address", map[string]interface{}{
+		"Address": address,
+		"Account": acc,
+		"Txs":     history.Txs,
+		"Next":    history.Next,
+		"Older":   cursor != "",
+		"Pending": pending,
+	})
+}
+
+// search redirects to the block, transaction or address q names.
+func (e *Explorer) search(w http.ResponseWriter, r *http.Request, q string) {
+	var target string
+	if _, err := strconv.Atoi(q); err == nil {
+		target = "block/" + q
+	} else if _, err := e.bc.BlockByHash(q); err == nil {
+		target = "block/" + q
+	} else if _, err := e.bc.TxByHash(q); err == nil {
+		target = "tx/" + q
+	} else if node.IsAddress(q) {
+		target = "address/" + q
+	}
+	if target == "" {
+		e.notFound(w, q)
+		return
+	}
+	http.Redirect(w, r, Path+target, http.StatusSeeOther)
+}
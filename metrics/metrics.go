@@ -0,0 +1,281 @@
+// Package metrics is a small registry of counters, gauges and histograms
+// that renders the Prometheus text exposition format. It uses only the
+// standard library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets are histogram buckets for durations in seconds.
+var DefBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// ---------------- REGISTRY ----------------
+// Registry holds metric families by name.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is one metric name with its help text, type and label names.
+// Each distinct set of label values is one series.
+type family struct {
+	name, help, typ string
+	labels          []string
+	buckets         []float64 // histograms only
+
+	mu     sync.Mutex
+	series map[string]series // by joined label values
+	order  []string
+}
+
+type series interface {
+	write(w io.Writer, name, labels string)
+}
+
+func (r *Registry) register(name, help, typ string, labels []string, buckets []float64) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	f := &family{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]series)}
+	r.families[name] = f
+	return f
+}
+
+// get returns the series for values, creating it with mk.
+func (f *family) get(values []string, mk func() series) series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = mk()
+		f.series[key] = s
+		f.order = append(f.order, key)
+	}
+	return s
+}
+
+// WriteText writes every metric in the Prometheus text format, sorted by
+// name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.mu.Lock()
+		f := r.families[name]
+		r.mu.Unlock()
+
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.typ)
+		f.mu.Lock()
+		keys := append([]string(nil), f.order...)
+		sort.Strings(keys)
+		for _, key := range keys {
+			var values []string
+			if len(f.labels) > 0 {
+				values = strings.Split(key, "\xff")
+			}
+			f.series[key].write(w, f.name, labelString(f.labels, values))
+		}
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// Handler serves the registry at GET requests.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// ---------------- COUNTER ----------------
+// Counter only goes up.
+type Counter struct{ v atomicFloat }
+
+func (c *Counter) Inc()          { c.v.add(1) }
+func (c *Counter) Add(d float64) { c.v.add(d) }
+func (c *Counter) Value() float64 {
+	return c.v.load()
+}
+func (c *Counter) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(c.v.load()))
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).With()
+}
+
+// CounterVec is a counter with labels.
+type CounterVec struct{ f *family }
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, "counter", labels, nil)}
+}
+
+// With returns the counter for the given label values.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.get(values, func() series { return &Counter{} }).(*Counter)
+}
+
+// ---------------- GAUGE ----------------
+// Gauge goes up and down.
+type Gauge struct{ v atomicFloat }
+
+func (g *Gauge) Set(x float64) { g.v.store(x) }
+func (g *Gauge) Add(d float64) { g.v.add(d) }
+func (g *Gauge) Value() float64 {
+	return g.v.load()
+}
+func (g *Gauge) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(g.v.load()))
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	f := r.register(name, help, "gauge", nil, nil)
+	return f.get(nil, func() series { return &Gauge{} }).(*Gauge)
+}
+
+// gaugeFunc is read when the metrics are written.
+type gaugeFunc func() float64
+
+func (g gaugeFunc) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(g()))
+}
+
+// NewGaugeFunc registers a gauge whose value is fn() at scrape time.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	f := r.register(name, help, "gauge", nil, nil)
+	f.get(nil, func() series { return gaugeFunc(fn) })
+}
+
+// ---------------- HISTOGRAM ----------------
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	buckets []float64
+	counts  []uint64 // per bucket, not cumulative; the last is +Inf
+	sum     atomicFloat
+	count   uint64
+}
+
+func (h *Histogram) Observe(x float64) {
+	i := sort.SearchFloat64s(h.buckets, x)
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	h.sum.add(x)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+func (h *Histogram) write(w io.Writer, name, labels string) {
+	var cum uint64
+	for i, le := range h.buckets {
+		cum += atomic.LoadUint64(&h.counts[i])
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", formatFloat(le)), cum)
+	}
+	cum += atomic.LoadUint64(&h.counts[len(h.buckets)])
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", "+Inf"), cum)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(h.sum.load()))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, cum)
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).With()
+}
+
+// HistogramVec is a histogram with labels.
+type HistogramVec struct{ f *family }
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{r.register(name, help, "histogram", labels, buckets)}
+}
+
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.get(values, func() series {
+		return &Histogram{buckets: v.f.buckets, counts: make([]uint64, len(v.f.buckets)+1)}
+	}).(*Histogram)
+}
+
+// ---------------- HELPERS ----------------
+type atomicFloat struct{ bits uint64 }
+
+func (a *atomicFloat) load() float64   { return math.Float64frombits(atomic.LoadUint64(&a.bits)) }
+func (a *atomicFloat) store(x float64) { atomic.StoreUint64(&a.bits, math.Float64bits(x)) }
+func (a *atomicFloat) add(d float64) {
+	for {
+		old := atomic.LoadUint64(&a.bits)
+		next := math.Float64bits(math.Float64frombits(old) + d)
+		if atomic.CompareAndSwapUint64(&a.bits, old, next) {
+			return
+		}
+	}
+}
+
+func formatFloat(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func labelString(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = n + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -5,32 +5,98 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 	"time"
 )
 
 // ---------------- BLOCK STRUCT ----------------
 type Block struct {
-	Index     int    `json:"Index"`
-	Timestamp string `json:"Timestamp"`
-	Data      string `json:"Data"`
-	PrevHash  string `json:"PrevHash"`
-	Hash      string `json:"Hash"`
+	Index     int           `json:"Index"`
+	Timestamp BlockTime     `json:"Timestamp"`
+	Data      string        `json:"Data"`
+	Txs       []Transaction `json:"Txs,omitempty"`
+	TxRoot    string        `json:"TxRoot"`
+	Proposer  string        `json:"Proposer"`
+	PrevHash  string        `json:"PrevHash"`
+	StateRoot string        `json:"StateRoot"`
+	Evidence  []Evidence    `json:"Evidence,omitempty"` // double signs punished by this block
+	Hash      string        `json:"Hash"`
+	Signature string        `json:"Signature,omitempty"` // the proposer's, over Hash; validators only
+}
+
+// Header is a block without its transactions and evidence, which it
+// commits to by root. It is enough to check the block's hash and
+// signature.
+type Header struct {
+	Index        int       `json:"Index"`
+	Timestamp    BlockTime `json:"Timestamp"`
+	Data         string    `json:"Data"`
+	TxRoot       string    `json:"TxRoot"`
+	Proposer     string    `json:"Proposer"`
+	PrevHash     string    `json:"PrevHash"`
+	StateRoot    string    `json:"StateRoot"`
+	EvidenceRoot string    `json:"EvidenceRoot,omitempty"`
+	Hash         string    `json:"Hash"`
+	Signature    string    `json:"Signature,omitempty"`
+}
+
+// Header returns the header of block.
+func (block Block) Header() Header {
+	return Header{
+		Index:        block.Index,
+		Timestamp:    block.Timestamp,
+		Data:         block.Data,
+		TxRoot:       block.TxRoot,
+		Proposer:     block.Proposer,
+		PrevHash:     block.PrevHash,
+		StateRoot:    block.StateRoot,
+		EvidenceRoot: EvidenceRoot(block.Evidence),
+		Hash:         block.Hash,
+		Signature:    block.Signature,
+	}
 }
 
 // ---------------- BLOCKCHAIN STRUCT ----------------
 type Blockchain struct {
 	Blocks  []Block   `json:"Blocks"`
-	Wallets []*Wallet `json:"Wallets"`
+	Wallets []*Wallet `json:"-"` // kept in wallets.json, never in the chain file
+
+	mu          sync.RWMutex
+	walletsMu   sync.RWMutex // guards Wallets; taken after mu, never before
+	path        string
+	walletsPath string // where LoadWallets found the local wallets
+	opts        ChainOptions
+	config      *Config
+	proposer    string         // address credited with fees of blocks made here
+	hooks       []EndBlockHook // run at the end of every block
+	evidence    []Evidence     // double signs waiting for a block made here
+	state       *State         // state after the last block
+	history     map[int]*State // state after recent blocks, by height
+	index       *chainIndex
+	metrics     *Metrics
+	logs        *nodeLogs
+	clock       Clock
 }
 
 // ---------------- HASH FUNCTION ----------------
 func CalculateHash(block Block) string {
-	record := fmt.Sprintf("%d%s%s%s",
+	return block.Header().CalculateHash()
+}
+
+// CalculateHash is the hash of the header fields. The evidence root only
+// counts when there is evidence, so blocks without any hash as before.
+func (block Header) CalculateHash() string {
+	record := fmt.Sprintf("%d%s%s%s%s%s%s%s",
 		block.Index,
-		block.Timestamp,
+		block.Timestamp.String(),
 		block.Data,
+		block.TxRoot,
+		block.Proposer,
 		block.PrevHash,
+		block.StateRoot,
+		block.EvidenceRoot,
 	)
 	h := sha256.New()
 	h.Write([]byte(record))
@@ -38,36 +104,413 @@ func CalculateHash(block Block) string {
 }
 
 // ---------------- GENESIS BLOCK ----------------
-func NewGenesisBlock() Block {
+// NewGenesisBlock builds the genesis block of cfg. It depends on nothing
+// but the spec, so every node loading the same spec gets the same block.
+func NewGenesisBlock(cfg *Config) Block {
 	block := Block{
 		Index:     0,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Data:      "Genesis Block",
+		Timestamp: NewBlockTime(cfg.Timestamp.UTC()),
+		Data:      "Genesis Block " + cfg.Hash(),
 		PrevHash:  "",
+		StateRoot: cfg.GenesisState().Root(),
 	}
 	block.Hash = CalculateHash(block)
 	return block
 }
 
+// NewBlockchain returns an in-memory chain holding only the genesis block
+// of DefaultConfig. It is never written to disk.
+func NewBlockchain() *Blockchain {
+	return NewBlockchainWithConfig(DefaultConfig())
+}
+
+// NewBlockchainWithConfig returns an in-memory chain holding only the
+// genesis block of cfg.
+func NewBlockchainWithConfig(cfg *Config) *Blockchain {
+	bc := &Blockchain{Blocks: []Block{NewGenesisBlock(cfg)}}
+	bc.init(cfg)
+	bc.replay()
+	bc.loadIndex(AllIndexes)
+	return bc
+}
+
+// init sets the defaults for fields that are not stored on disk.
+func (bc *Blockchain) init(cfg *Config) {
+	bc.opts = DefaultChainOptions
+	bc.config = cfg
+	bc.hooks = []EndBlockHook{CreditFees, bc.punishDoubleSigns}
+	bc.metrics = NewMetrics()
+	bc.logs = newNodeLogs("")
+	bc.clock = SystemClock{}
+}
+
+func (bc *Blockchain) Config() *Config {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.config
+}
+
+// GenesisHash is the hash of block 0.
+func (bc *Blockchain) GenesisHash() string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.Blocks[0].Hash
+}
+
+// SetProposer sets the address that receives the fees of blocks produced
+// by this node.
+func (bc *Blockchain) SetProposer(address string) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.proposer = address
+}
+
+func (bc *Blockchain) Proposer() string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.proposer
+}
+
+// SetOptions changes snapshot and pruning behaviour and prunes at once.
+func (bc *Blockchain) SetOptions(opts ChainOptions) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.opts = opts
+	bc.prune()
+}
+
 // ---------------- ADD BLOCK ----------------
-func (bc *Blockchain) AddBlock(data string) {
-	if len(bc.Blocks) == 0 {
-		genesis := NewGenesisBlock()
-		bc.Blocks = append(bc.Blocks, genesis)
-		bc.Save("blocks.json")
-		return
-	}
+// AddBlock commits a block without transactions.
+func (bc *Blockchain) AddBlock(data string) error {
+	_, err := bc.CommitBlock(data, nil)
+	return err
+}
+
+// CommitBlock applies txs on top of the current state and appends a block
+// whose header commits to the resulting state root. The block carries the
+// pending double-sign evidence and, on a chain with validators, the
+// signature of the proposer, which must own the block's slot.
+func (bc *Blockchain) CommitBlock(data string, txs []Transaction) (Block, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	start := time.Now()
 
 	prevBlock := bc.Blocks[len(bc.Blocks)-1]
+	timestamp, err := bc.nextTimestamp(bc.recentBlocks(prevBlock.Index), bc.clock.Now())
+	if err != nil {
+		return Block{}, err
+	}
 	newBlock := Block{
 		Index:     prevBlock.Index + 1,
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: timestamp,
 		Data:      data,
+		Txs:       txs,
+		TxRoot:    TxRoot(txs),
+		Proposer:  bc.proposer,
 		PrevHash:  prevBlock.Hash,
+		Evidence:  append([]Evidence(nil), bc.evidence...),
+	}
+
+	if err := bc.checkSlot(bc.state, newBlock); err != nil {
+		bc.logs.consensus.Warn("cannot propose", "height", newBlock.Index, "err", err)
+		return Block{}, err
 	}
+	if err := checkLimits(newBlock, bc.config.BlockLimits()); err != nil {
+		return Block{}, err
+	}
+	next := bc.state.Copy()
+	if err := bc.applyBlock(next, newBlock); err != nil {
+		bc.metrics.InvalidBlocks.Inc()
+		return Block{}, err
+	}
+	newBlock.StateRoot = next.Root()
 	newBlock.Hash = CalculateHash(newBlock)
-	bc.Blocks = append(bc.Blocks, newBlock)
-	bc.Save("blocks.json")
+	if err := bc.sign(&newBlock); err != nil {
+		return Block{}, err
+	}
+	bc.logs.chain.Info("block committed", "height", newBlock.Index, "hash", newBlock.Hash,
+		"txs", len(txs), "proposer", newBlock.Proposer)
+	err = bc.appendBlock(newBlock, next)
+	bc.metrics.BlockCommit.Observe(time.Since(start).Seconds())
+	return newBlock, err
+}
+
+// ImportBlock appends a block made by another node. It must extend our
+// head, and its hash, tx root and state root must match what we compute.
+func (bc *Blockchain) ImportBlock(block Block) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	start := time.Now()
+
+	head := bc.Blocks[len(bc.Blocks)-1]
+	switch {
+	case block.Index < 1:
+		bc.metrics.InvalidBlocks.Inc()
+		return fmt.Errorf("%w: block %d is not above genesis", ErrInvalidBlock, block.Index)
+	case block.Index > head.Index+1:
+		return fmt.Errorf("%w: block %d, our head is %d", ErrFutureBlock, block.Index, head.Index)
+	case block.Index <= head.Index && bc.Blocks[block.Index].Hash == block.Hash:
+		return ErrKnownBlock
+	case block.Index <= head.Index:
+		return fmt.Errorf("%w: block %d, our head is %d", ErrShorterFork, block.Index, head.Index)
+	case block.PrevHash != head.Hash:
+		return fmt.Errorf("%w: block %d does not extend our head %s", ErrUnknownParent, block.Index, head.Hash)
+	}
+	if err := bc.checkTimestamp(bc.recentBlocks(head.Index), block, bc.clock.Now()); err != nil {
+		bc.metrics.InvalidBlocks.Inc()
+		return err
+	}
+	next, err := bc.checkBlock(head, bc.state, block)
+	if err != nil {
+		return err
+	}
+	bc.logs.chain.Info("block imported", "height", block.Index, "hash", block.Hash,
+		"txs", len(block.Txs), "proposer", block.Proposer)
+	err = bc.appendBlock(block, next)
+	bc.metrics.BlockCommit.Observe(time.Since(start).Seconds())
+	return err
+}
+
+// checkBlock checks that block follows parent, that its hash, tx root,
+// signature, proposer and state root are right and that it keeps to the
+// block limits. It returns the state after the block, which is applied to a
+// copy of state.
+func (bc *Blockchain) checkBlock(parent Block, state *State, block Block) (*State, error) {
+	switch {
+	case block.Index != parent.Index+1 || block.PrevHash != parent.Hash:
+		return nil, fmt.Errorf("%w: block %d does not follow block %d", ErrInvalidBlock, block.Index, parent.Index)
+	case CalculateHash(block) != block.Hash:
+		return nil, fmt.Errorf("%w: block %d hash mismatch", ErrInvalidBlock, block.Index)
+	case TxRoot(block.Txs) != block.TxRoot:
+		return nil, fmt.Errorf("%w: block %d tx root mismatch", ErrInvalidBlock, block.Index)
+	}
+	if err := bc.checkSignature(block); err != nil {
+		bc.metrics.InvalidBlocks.Inc()
+		bc.logs.consensus.Warn("block refused", "height", block.Index, "proposer", block.Proposer, "err", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBlock, err)
+	}
+	if err := bc.checkSlot(state, block); err != nil {
+		bc.metrics.InvalidBlocks.Inc()
+		bc.logs.consensus.Warn("block refused", "height", block.Index, "proposer", block.Proposer, "err", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBlock, err)
+	}
+	if err := checkLimits(block, bc.config.BlockLimits()); err != nil {
+		bc.metrics.InvalidBlocks.Inc()
+		return nil, fmt.Errorf("%w: block %d: %w", ErrInvalidBlock, block.Index, err)
+	}
+	next := state.Copy()
+	if err := bc.applyBlock(next, block); err != nil {
+		bc.metrics.InvalidBlocks.Inc()
+		return nil, fmt.Errorf("%w: block %d: %w", ErrInvalidBlock, block.Index, err)
+	}
+	if root := next.Root(); root != block.StateRoot {
+		bc.metrics.InvalidBlocks.Inc()
+		return nil, fmt.Errorf("%w: block %d state root %s, computed %s", ErrInvalidBlock, block.Index, block.StateRoot, root)
+	}
+	return next, nil
+}
+
+// appendBlock makes block, with state after it, the new head and writes
+// snapshots and the chain file. The caller holds bc.mu.
+func (bc *Blockchain) appendBlock(block Block, state *State) error {
+	if err := bc.extend(block, state); err != nil {
+		return err
+	}
+	bc.prune()
+	if err := bc.save(); err != nil {
+		bc.logs.chain.Error("saving chain failed", "height", block.Index, "err", err)
+		return err
+	}
+	return nil
+}
+
+// extend makes block the new head in memory and in the indexes, and
+// writes a snapshot if one is due. The caller holds bc.mu.
+func (bc *Blockchain) extend(block Block, state *State) error {
+	prev := bc.Blocks[len(bc.Blocks)-1]
+	bc.Blocks = append(bc.Blocks, block)
+	bc.state = state
+	bc.history[block.Index] = state
+	bc.index.add(block)
+	for _, e := range block.Evidence {
+		bc.logs.consensus.Warn("validator jailed", "validator", e.Validator(), "double_sign_height", e.Height(),
+			"until_epoch", state.Jails[e.Validator()].Until, "height", block.Index)
+	}
+	bc.pruneEvidence(state)
+	bc.recordBlock(prev, block)
+
+	if n := bc.opts.SnapshotInterval; n > 0 && block.Index%n == 0 {
+		if err := bc.writeSnapshot(newSnapshot(block, state)); err != nil {
+			bc.logs.chain.Error("writing snapshot failed", "height", block.Index, "err", err)
+			return err
+		}
+		bc.metrics.SnapshotsTaken.Inc()
+	}
+	return nil
+}
+
+// Head returns the last block.
+func (bc *Blockchain) Head() Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.Blocks[len(bc.Blocks)-1]
+}
+
+// recordBlock updates the chain metrics for a block appended after prev.
+func (bc *Blockchain) recordBlock(prev, block Block) {
+	bc.metrics.Blocks.Inc()
+	bc.metrics.Height.Set(float64(block.Index))
+	bc.metrics.BlockTxs.Observe(float64(len(block.Txs)))
+	if prev.Index > 0 {
+		bc.metrics.BlockInterval.Observe(block.Timestamp.Sub(prev.Timestamp.Time).Seconds())
+	}
+}
+
+// ---------------- STATE ----------------
+// replay rebuilds the account state from the newest usable snapshot, or
+// from genesis when there is none. Invalid transactions are skipped here;
+// ValidateChain reports them.
+func (bc *Blockchain) replay() {
+	bc.history = map[int]*State{}
+	state, start := bc.config.GenesisState(), 0
+	if snap := bc.latestSnapshot(len(bc.Blocks) - 1); snap != nil {
+		state, start = snap.State(), snap.Height+1
+		bc.history[snap.Height] = state
+	}
+	for _, block := range bc.Blocks[start:] {
+		state = state.Copy()
+		if err := bc.applyBlock(state, block); err != nil {
+			bc.logs.chain.Warn("invalid block during replay", "height", block.Index, "hash", block.Hash, "err", err)
+			bc.metrics.InvalidBlocks.Inc()
+		}
+		bc.history[block.Index] = state
+	}
+	bc.state = state
+	bc.prune()
+	bc.metrics.Height.Set(float64(len(bc.Blocks) - 1))
+	bc.logs.chain.Debug("state rebuilt", "height", len(bc.Blocks)-1, "from", start)
+}
+
+// checkLimits checks the number and total size of block's transactions
+// against l. RuleSize checks each transaction on its own.
+func checkLimits(block Block, l Limits) error {
+	if l.MaxBlockTxs > 0 && len(block.Txs) > l.MaxBlockTxs {
+		return fmt.Errorf("%w: %d transactions, limit %d", ErrTooManyTxs, len(block.Txs), l.MaxBlockTxs)
+	}
+	if size := txBytes(block.Txs); l.MaxBlockBytes > 0 && size > l.MaxBlockBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrBlockTooLarge, size, l.MaxBlockBytes)
+	}
+	return nil
+}
+
+// txBytes is the encoded size of txs, which is what MaxBlockBytes limits.
+func txBytes(txs []Transaction) int {
+	n := 0
+	for _, tx := range txs {
+		n += tx.Size()
+	}
+	return n
+}
+
+// applyBlock runs the block's transactions and then the end-of-block hooks
+// on state. It stops at the first error, leaving state partly updated.
+func (bc *Blockchain) applyBlock(state *State, block Block) error {
+	ctx := bc.ruleContext(state)
+	for _, tx := range block.Txs {
+		if err := ValidateTx(tx, ctx, StatelessRules); err != nil {
+			return err
+		}
+		if err := state.ApplyTx(tx); err != nil {
+			return err
+		}
+	}
+	for _, hook := range bc.hooks {
+		if err := hook(state, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateAt returns the state after the block at height. States that are no
+// longer in memory are rebuilt from the nearest snapshot below them.
+// A block that does not apply during the rebuild is an error, never a
+// half-applied state.
+func (bc *Blockchain) stateAt(height int) (*State, error) {
+	if state, ok := bc.history[height]; ok {
+		return state, nil
+	}
+	if bc.opts.PruneDepth > 0 && height < len(bc.Blocks)-1-bc.opts.PruneDepth {
+		return nil, ErrStatePruned
+	}
+	state, start := bc.config.GenesisState(), 0
+	if snap := bc.latestSnapshot(height); snap != nil {
+		state, start = snap.State(), snap.Height+1
+	}
+	for _, block := range bc.Blocks[start : height+1] {
+		if err := bc.applyBlock(state, block); err != nil {
+			return nil, fmt.Errorf("replaying block %d: %w", block.Index, err)
+		}
+	}
+	return state, nil
+}
+
+// State returns the account state after the last block. It must not be
+// modified by the caller.
+func (bc *Blockchain) State() *State {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.state
+}
+
+// Height returns the index of the last block.
+func (bc *Blockchain) Height() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.Blocks[len(bc.Blocks)-1].Index
+}
+
+// BlocksFrom returns a copy of the blocks from height onwards.
+func (bc *Blockchain) BlocksFrom(height int) []Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if height < 0 {
+		height = 0
+	}
+	if height >= len(bc.Blocks) {
+		return []Block{}
+	}
+	return append([]Block(nil), bc.Blocks[height:]...)
+}
+
+// GetProof proves the account at address against the state root of the
+// block at height. A negative height means the last block.
+func (bc *Blockchain) GetProof(address string, height int) (*AccountProof, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if height < 0 {
+		height = len(bc.Blocks) - 1
+	}
+	if height >= len(bc.Blocks) {
+		return nil, fmt.Errorf("block %d not found", height)
+	}
+	state, err := bc.stateAt(height)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %w", height, err)
+	}
+
+	proof := &AccountProof{
+		Address:   address,
+		Height:    height,
+		StateRoot: bc.Blocks[height].StateRoot,
+		Proof:     state.Prove(address),
+	}
+	if acc := state.Get(address); acc != nil {
+		a := *acc
+		proof.Account = &a
+	}
+	return proof, nil
 }
 
 // ---------------- SAVE BLOCKCHAIN ----------------
@@ -82,14 +525,32 @@ func (bc *Blockchain) Save(filename string) error {
 	return encoder.Encode(bc)
 }
 
+// save writes the chain and its indexes back to the files they were
+// loaded from, if any.
+func (bc *Blockchain) save() error {
+	if bc.path == "" {
+		return nil
+	}
+	if err := bc.Save(bc.path); err != nil {
+		return err
+	}
+	return bc.saveIndex()
+}
+
 // ---------------- LOAD BLOCKCHAIN ----------------
-func LoadBlockchain(filename string) (*Blockchain, error) {
+// LoadBlockchain opens the chain file of the network described by cfg
+// (DefaultConfig if nil), creating it if it does not exist. A file whose
+// genesis block does not match cfg is refused with ErrGenesisMismatch.
+func LoadBlockchain(filename string, cfg *Config) (*Blockchain, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
 	file, err := os.Open(filename)
 	if err != nil {
-		genesis := NewGenesisBlock()
-		bc := &Blockchain{Blocks: []Block{genesis}}
-		bc.Save(filename)
-		return bc, nil
+		bc := NewBlockchainWithConfig(cfg)
+		bc.path = filename
+		bc.logs.chain.Info("new chain created", "path", filename, "hash", bc.Blocks[0].Hash)
+		return bc, bc.save()
 	}
 	defer file.Close()
 
@@ -99,35 +560,38 @@ func LoadBlockchain(filename string) (*Blockchain, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(bc.Blocks) == 0 {
+		return nil, fmt.Errorf("%s holds no blocks", filename)
+	}
+	if want := NewGenesisBlock(cfg).Hash; bc.Blocks[0].Hash != want {
+		return nil, fmt.Errorf("%w: %s starts with %s, the spec gives %s",
+			ErrGenesisMismatch, filename, bc.Blocks[0].Hash, want)
+	}
+	bc.path = filename
+	bc.init(cfg)
+	bc.replay()
+	bc.loadIndex(AllIndexes)
+	head := bc.Blocks[len(bc.Blocks)-1]
+	bc.logs.chain.Info("chain loaded", "path", filename, "height", head.Index, "hash", head.Hash)
 	return &bc, nil
 }
 
 // ---------------- SHOW BLOCKS ----------------
 func (bc *Blockchain) ShowChain() {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
 	fmt.Println("\n📦 Blockchain:")
 	out, _ := json.MarshalIndent(bc.Blocks, "", "  ")
 	fmt.Println(string(out))
 }
 
 // ---------------- VALIDATE BLOCKCHAIN ----------------
-func (bc *Blockchain) ValidateChain() {
-	fmt.Println("\n🔍 Validating Blockchain...")
-	for i := 1; i < len(bc.Blocks); i++ {
-		current := bc.Blocks[i]
-		previous := bc.Blocks[i-1]
-
-		if current.PrevHash != previous.Hash {
-			fmt.Println("❌ Chain broken at block", current.Index)
-			fmt.Println("Expected:", previous.Hash)
-			fmt.Println("Got     :", current.PrevHash)
-			return
-		}
-
-		validHash := CalculateHash(current)
-		if current.Hash != validHash {
-			fmt.Println("❌ Invalid hash at block", current.Index)
-			return
-		}
-	}
-	fmt.Println("✅ Blockchain is valid and secure.")
+// ValidateChain re-checks every block from genesis up to the first
+// problem, writes what it finds to w and reports whether the chain is
+// valid. VerifyChain does the checks.
+func (bc *Blockchain) ValidateChain(w io.Writer) bool {
+	fmt.Fprintln(w, "\n🔍 Validating Blockchain...")
+	report := bc.VerifyChain(false)
+	report.WriteText(w)
+	return report.Valid
 }
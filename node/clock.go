@@ -0,0 +1,229 @@
+package node
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ---------------- CLOCK ----------------
+// Clock is where a node gets the time: block timestamps, mempool arrival
+// and expiry, peer status and the HELLO ticker all read it. SystemClock is
+// the real time; a SimClock only moves when told to, so tests can skip
+// ahead instantly and runs can be repeated exactly. Socket deadlines stay
+// on real time, as the operating system enforces them.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on C until stopped, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the real time.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time                         { return time.Now() }
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (SystemClock) NewTicker(d time.Duration) Ticker       { return systemTicker{time.NewTicker(d)} }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// SetClock makes the chain, and the mempool and network built on it, use
+// c for the time.
+func (bc *Blockchain) SetClock(c Clock) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.clock = c
+}
+
+// Clock returns the clock of the chain.
+func (bc *Blockchain) Clock() Clock {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.clock
+}
+
+// ---------------- SIMULATED CLOCK ----------------
+// SimClock is a clock that stands still until Advance or Set moves it.
+// Timers and tickers fire, in order, as it passes their deadlines. Like
+// time.Ticker, a ticker that is not read drops ticks.
+type SimClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*simWaiter
+}
+
+type simWaiter struct {
+	at     time.Time
+	period time.Duration // 0 for a one-shot timer
+	ch     chan time.Time
+}
+
+// NewSimClock returns a simulated clock showing start.
+func NewSimClock(start time.Time) *SimClock {
+	return &SimClock{now: start}
+}
+
+func (c *SimClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *SimClock) After(d time.Duration) <-chan time.Time {
+	return c.wait(d, 0).ch
+}
+
+func (c *SimClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for SimClock.NewTicker")
+	}
+	return &simTicker{clock: c, w: c.wait(d, d)}
+}
+
+func (c *SimClock) wait(d, period time.Duration) *simWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &simWaiter{at: c.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- c.now
+		return w
+	}
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+// Advance moves the clock forward by d.
+func (c *SimClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every timer and tick due by then.
+// The clock never goes back.
+func (c *SimClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		sort.SliceStable(c.waiters, func(a, b int) bool { return c.waiters[a].at.Before(c.waiters[b].at) })
+		if len(c.waiters) == 0 || c.waiters[0].at.After(t) {
+			break
+		}
+		w := c.waiters[0]
+		c.now = w.at
+		select {
+		case w.ch <- w.at:
+		default:
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			c.waiters = c.waiters[1:]
+		}
+	}
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+func (c *SimClock) remove(w *simWaiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, x := range c.waiters {
+		if x == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type simTicker struct {
+	clock *SimClock
+	w     *simWaiter
+}
+
+func (t *simTicker) C() <-chan time.Time { return t.w.ch }
+func (t *simTicker) Stop()               { t.clock.remove(t.w) }
+
+// ---------------- RECORD AND REPLAY ----------------
+// A run is recorded by wrapping its clock with RecordClock, which writes
+// every reading of Now as a line. ReplayClock reads such a log back and
+// answers Now with the recorded times in order, so the same steps produce
+// the same timestamps and hashes.
+
+// RecordClock returns a clock that reads c and writes each Now to w.
+func RecordClock(c Clock, w io.Writer) Clock {
+	return &recordingClock{Clock: c, w: w}
+}
+
+type recordingClock struct {
+	Clock
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (c *recordingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.Clock.Now()
+	fmt.Fprintln(c.w, t.Format(time.RFC3339Nano))
+	return t
+}
+
+// ReplayClock is a SimClock that moves to the next recorded time at every
+// Now. Once the log is used up it stands still.
+type ReplayClock struct {
+	*SimClock
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// NewReplayClock reads a log written by RecordClock.
+func NewReplayClock(r io.Reader) (*ReplayClock, error) {
+	var times []time.Time
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		t, err := time.Parse(time.RFC3339Nano, scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("clock log line %d: %w", line, err)
+		}
+		times = append(times, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("clock log is empty")
+	}
+	return &ReplayClock{SimClock: NewSimClock(times[0]), times: times}, nil
+}
+
+func (c *ReplayClock) Now() time.Time {
+	c.mu.Lock()
+	if len(c.times) > 0 {
+		next := c.times[0]
+		c.times = c.times[1:]
+		c.mu.Unlock()
+		c.SimClock.Set(next)
+		return next
+	}
+	c.mu.Unlock()
+	return c.SimClock.Now()
+}
+
+// Remaining is the number of recorded times not replayed yet.
+func (c *ReplayClock) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.times)
+}
@@ -0,0 +1,96 @@
+package node
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+var simStart = time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestSimClockTimers(t *testing.T) {
+	c := NewSimClock(simStart)
+	after := c.After(3 * time.Second)
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	c.Advance(1500 * time.Millisecond)
+	if got := <-ticker.C(); !got.Equal(simStart.Add(time.Second)) {
+		t.Fatalf("first tick at %v", got)
+	}
+	select {
+	case <-after:
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(2 * time.Second)
+	if got := <-after; !got.Equal(simStart.Add(3 * time.Second)) {
+		t.Fatalf("timer fired at %v", got)
+	}
+	// Ticks nobody read were dropped, like time.Ticker does.
+	if got := <-ticker.C(); !got.Equal(simStart.Add(2 * time.Second)) {
+		t.Fatalf("buffered tick at %v", got)
+	}
+	if !c.Now().Equal(simStart.Add(3500 * time.Millisecond)) {
+		t.Fatalf("now is %v", c.Now())
+	}
+	c.Set(simStart)
+	if !c.Now().Equal(simStart.Add(3500 * time.Millisecond)) {
+		t.Fatal("the clock went back")
+	}
+}
+
+func TestSimClockMakesChainsRepeatable(t *testing.T) {
+	build := func() *Blockchain {
+		bc := NewBlockchainWithConfig(fundedSpec(10, "alice"))
+		bc.SetClock(NewSimClock(simStart))
+		bc.CommitBlock("one", []Transaction{transfer("alice", "bob", 10, 0, 0)})
+		return bc
+	}
+	a, b := build(), build()
+	if a.Head().Hash != b.Head().Hash {
+		t.Fatal("same steps on the same clock gave different blocks")
+	}
+	if a.Head().Timestamp.String() != simStart.Format(time.RFC3339) {
+		t.Fatalf("timestamp %s", a.Head().Timestamp)
+	}
+}
+
+func TestMempoolExpiresOnSimClock(t *testing.T) {
+	bc := newTestChain(t, DefaultChainOptions, 0)
+	clock := NewSimClock(simStart)
+	bc.SetClock(clock)
+	mp := NewMempool(bc, DefaultMempoolConfig)
+	if err := mp.Add(transfer("alice", "bob", 1, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(DefaultMempoolConfig.TTL + time.Second)
+	if tmpl := mp.Template(Limits{}); len(tmpl.Txs) != 0 || len(mp.Pending()) != 0 {
+		t.Fatalf("expired tx still pending: %+v", tmpl.Txs)
+	}
+}
+
+func TestRecordAndReplayClock(t *testing.T) {
+	var log bytes.Buffer
+	sim := NewSimClock(simStart)
+	rec := RecordClock(sim, &log)
+	var recorded []time.Time
+	for i := 0; i < 3; i++ {
+		recorded = append(recorded, rec.Now())
+		sim.Advance(time.Duration(i+1) * time.Millisecond)
+	}
+
+	replay, err := NewReplayClock(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range recorded {
+		if got := replay.Now(); !got.Equal(want) {
+			t.Fatalf("reading %d: got %v, want %v", i, got, want)
+		}
+	}
+	if replay.Remaining() != 0 || !replay.Now().Equal(recorded[2]) {
+		t.Fatal("a used-up replay clock should stand still")
+	}
+}
@@ -1,30 +1,210 @@
 package node
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"time"
 )
 
-// Config defines your blockchain configuration
+// ErrInvalidGenesis wraps every reason a genesis spec is refused.
+var ErrInvalidGenesis = errors.New("invalid genesis")
+
+// Config is the genesis spec of a chain. Every node of a network must load
+// the same one: the genesis block commits to it, so a node with different
+// parameters ends up with a different genesis hash.
 type Config struct {
-	ChainID          string    `json:"chain_id"`
-	EpochDurationSec int       `json:"epoch_duration_sec"`
-	InitialSupply    int       `json:"initial_supply"`
-	Timestamp        time.Time `json:"timestamp"`
+	ChainID           string             `json:"chain_id"`
+	Timestamp         time.Time          `json:"timestamp"`
+	InitialSupply     int                `json:"initial_supply"`
+	Alloc             []GenesisAlloc     `json:"alloc"`      // must add up to InitialSupply
+	Validators        []GenesisValidator `json:"validators"` // the PoA validator set
+	Consensus         ConsensusParams    `json:"consensus"`
+	Limits            Limits             `json:"limits"`
+	MinTxFee          int                `json:"min_tx_fee"`          // smallest fee a transfer may pay
+	AllowSelfTransfer bool               `json:"allow_self_transfer"` // may From and To be the same
+}
+
+// GenesisAlloc is a balance present from the genesis block on.
+type GenesisAlloc struct {
+	Address string `json:"address"`
+	Balance int    `json:"balance"`
+}
+
+// GenesisValidator is a key allowed to propose blocks.
+type GenesisValidator struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+	PubKey  string `json:"pub_key"`
+}
+
+// ConsensusParams are the timing parameters of proof of authority.
+type ConsensusParams struct {
+	SlotDurationSec  int `json:"slot_duration_sec"`     // time each proposer gets for a block
+	EpochDurationSec int `json:"epoch_duration_sec"`    // time between validator set changes
+	JailEpochs       int `json:"jail_epochs,omitempty"` // epochs a double signer sits out; 0 means DefaultJailEpochs
+}
+
+// DefaultJailEpochs is how long a double signer is jailed when the spec
+// does not say.
+const DefaultJailEpochs = 2
+
+// DevSupply is what DefaultConfig allocates to the DevWallet.
+const DevSupply = 1000000
+
+// Limits bound the size of blocks and transactions.
+type Limits struct {
+	MaxBlockBytes int `json:"max_block_bytes"`
+	MaxBlockTxs   int `json:"max_block_txs"`
+	MaxTxBytes    int `json:"max_tx_bytes"`
 }
 
-// LoadConfig loads config from a JSON file
+// BlockLimits returns the spec's limits with the defaults in place of
+// those left at 0. MaxBlockTxs has no default: 0 means no limit.
+func (c *Config) BlockLimits() Limits {
+	l := c.Limits
+	if l.MaxBlockBytes == 0 {
+		l.MaxBlockBytes = MaxBlockBytes
+	}
+	if l.MaxTxBytes == 0 {
+		l.MaxTxBytes = MaxTxBytes
+	}
+	return l
+}
+
+// allocated reports whether the genesis alloc funds address.
+func (c *Config) allocated(address string) bool {
+	for _, a := range c.Alloc {
+		if a.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultConfig is a single-node development chain without validators.
+// Its whole supply belongs to the DevWallet.
+func DefaultConfig() *Config {
+	return &Config{
+		ChainID:       "proco-testnet",
+		Timestamp:     time.Date(2025, 12, 3, 20, 0, 0, 0, time.UTC),
+		InitialSupply: DevSupply,
+		Alloc:         []GenesisAlloc{{Address: DevWallet().Address, Balance: DevSupply}},
+		Consensus:     ConsensusParams{SlotDurationSec: 5, EpochDurationSec: 300},
+		Limits:        Limits{MaxBlockBytes: MaxBlockBytes, MaxBlockTxs: 1000, MaxTxBytes: MaxTxBytes},
+	}
+}
+
+// LoadConfig reads a genesis spec from a JSON file. Unknown fields are an
+// error, so a misspelt key cannot silently fall back to a default.
 func LoadConfig(path string) (*Config, error) {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	return ParseConfig(data)
+}
 
+// ParseConfig decodes and validates a genesis spec.
+func ParseConfig(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
 	var cfg Config
-	if err := json.NewDecoder(file).Decode(&cfg); err != nil {
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGenesis, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: trailing data after the spec", ErrInvalidGenesis)
+	}
+	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 	return &cfg, nil
 }
+
+// Validate checks that the spec is self-consistent.
+func (c *Config) Validate() error {
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidGenesis, fmt.Sprintf(format, args...))
+	}
+	if c.ChainID == "" {
+		return fail("chain_id is empty")
+	}
+	if c.Timestamp.IsZero() {
+		return fail("timestamp is missing")
+	}
+	if c.InitialSupply < 0 || c.MinTxFee < 0 {
+		return fail("initial_supply and min_tx_fee must not be negative")
+	}
+
+	total := 0
+	seen := map[string]bool{}
+	for _, a := range c.Alloc {
+		if !isAddress(a.Address) {
+			return fail("alloc: bad address %q", a.Address)
+		}
+		if seen[a.Address] {
+			return fail("alloc: %s listed twice", a.Address)
+		}
+		seen[a.Address] = true
+		if a.Balance <= 0 {
+			return fail("alloc: %s has a balance of %d", a.Address, a.Balance)
+		}
+		if a.Balance > c.InitialSupply-total {
+			return fail("alloc: balances exceed initial_supply %d", c.InitialSupply)
+		}
+		total += a.Balance
+	}
+	if total != c.InitialSupply {
+		return fail("alloc: balances add up to %d, initial_supply is %d", total, c.InitialSupply)
+	}
+
+	seen = map[string]bool{}
+	for _, v := range c.Validators {
+		pub, err := hex.DecodeString(v.PubKey)
+		if err != nil || len(pub) != 32 {
+			return fail("validator %s: malformed pub_key", v.Address)
+		}
+		if AddressFromPublicKey(pub) != v.Address {
+			return fail("validator %s: address does not match pub_key", v.Address)
+		}
+		if seen[v.Address] {
+			return fail("validator %s listed twice", v.Address)
+		}
+		seen[v.Address] = true
+	}
+
+	if c.Consensus.SlotDurationSec < 0 || c.Consensus.EpochDurationSec < 0 || c.Consensus.JailEpochs < 0 {
+		return fail("consensus durations must not be negative")
+	}
+	l := c.Limits
+	if l.MaxBlockBytes < 0 || l.MaxBlockTxs < 0 || l.MaxTxBytes < 0 {
+		return fail("limits must not be negative")
+	}
+	if l.MaxBlockBytes > 0 && l.MaxTxBytes > l.MaxBlockBytes {
+		return fail("max_tx_bytes %d is larger than max_block_bytes %d", l.MaxTxBytes, l.MaxBlockBytes)
+	}
+	return nil
+}
+
+// Hash is the sha256 of the canonical encoding of the spec.
+func (c *Config) Hash() string {
+	spec := *c
+	spec.Timestamp = spec.Timestamp.UTC()
+	b, _ := json.Marshal(spec)
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// GenesisState is the account state before the first transaction.
+func (c *Config) GenesisState() *State {
+	state := NewState()
+	for _, a := range c.Alloc {
+		state.account(a.Address).Balance = a.Balance
+	}
+	return state
+}
@@ -0,0 +1,162 @@
+package node
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testSpec() *Config {
+	cfg := DefaultConfig()
+	cfg.InitialSupply = 1000
+	cfg.Alloc = []GenesisAlloc{{Address: addr("alice"), Balance: 600}, {Address: addr("bob"), Balance: 400}}
+	v := testWallet("validator")
+	pub, _ := hex.DecodeString(v.PublicKey)
+	cfg.Validators = []GenesisValidator{{Name: "v1", Address: AddressFromPublicKey(pub), PubKey: v.PublicKey}}
+	return cfg
+}
+
+func TestGenesisIsDeterministic(t *testing.T) {
+	a, b := NewBlockchainWithConfig(testSpec()), NewBlockchainWithConfig(testSpec())
+	if a.GenesisHash() != b.GenesisHash() {
+		t.Fatal("same spec produced different genesis blocks")
+	}
+	if a.State().Balance(addr("alice")) != 600 || a.State().Balance(addr("bob")) != 400 {
+		t.Fatal("allocations missing from genesis state")
+	}
+
+	other := testSpec()
+	other.Limits.MaxBlockTxs = 5
+	if NewBlockchainWithConfig(other).GenesisHash() == a.GenesisHash() {
+		t.Fatal("genesis does not commit to the spec")
+	}
+}
+
+func TestGenesisValidation(t *testing.T) {
+	if err := testSpec().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for name, mutate := range map[string]func(*Config){
+		"supply mismatch":   func(c *Config) { c.InitialSupply = 999 },
+		"duplicate alloc":   func(c *Config) { c.Alloc[1].Address = c.Alloc[0].Address },
+		"bad alloc address": func(c *Config) { c.Alloc[0].Address = "alice" },
+		"no chain id":       func(c *Config) { c.ChainID = "" },
+		"foreign validator": func(c *Config) { c.Validators[0].Address = addr("alice") },
+		"tx over block":     func(c *Config) { c.Limits.MaxTxBytes = c.Limits.MaxBlockBytes + 1 },
+	} {
+		cfg := testSpec()
+		mutate(cfg)
+		if err := cfg.Validate(); !errors.Is(err, ErrInvalidGenesis) {
+			t.Errorf("%s: expected invalid genesis, got %v", name, err)
+		}
+	}
+}
+
+func TestParseConfigIsStrict(t *testing.T) {
+	if _, err := LoadConfig(filepath.Join("..", "config", "genesis.json")); err != nil {
+		t.Fatalf("shipped genesis rejected: %v", err)
+	}
+	_, err := ParseConfig([]byte(`{"chain_id":"x","timestamp":"2025-12-03T20:00:00Z","epoch_duration_secs":5}`))
+	if !errors.Is(err, ErrInvalidGenesis) || !strings.Contains(err.Error(), "epoch_duration_secs") {
+		t.Fatalf("unknown field accepted: %v", err)
+	}
+}
+
+func TestLoadBlockchainChecksGenesis(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.json")
+	if _, err := LoadBlockchain(path, testSpec()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBlockchain(path, testSpec()); err != nil {
+		t.Fatalf("reload with the same spec failed: %v", err)
+	}
+	if _, err := LoadBlockchain(path, DefaultConfig()); !errors.Is(err, ErrGenesisMismatch) {
+		t.Fatalf("expected genesis mismatch, got %v", err)
+	}
+}
+
+func TestInitNetwork(t *testing.T) {
+	dir := t.TempDir()
+	spec, err := InitNetwork(InitOptions{
+		Dir: dir, ChainID: "classroom", Validators: 3,
+		Alloc: map[string]int{"node1": 500, addr("alice"): 100},
+		Host:  "127.0.0.1", P2PPort: 4001, RPCPort: 9545,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Validators) != 3 || spec.InitialSupply != 600 {
+		t.Fatalf("unexpected spec: %+v", spec)
+	}
+
+	var hashes []string
+	for _, v := range spec.Validators {
+		ncfg, err := LoadNodeConfig(filepath.Join(dir, v.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ncfg.Proposer != v.Address || len(ncfg.Peers) != 2 {
+			t.Fatalf("%s: unexpected node config %+v", v.Name, ncfg)
+		}
+		cfg, err := ncfg.LoadGenesis()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bc, err := LoadBlockchain(ncfg.Path(ChainFile), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bc.LoadWallets(ncfg.Path(WalletsFile)); err != nil || FindWallet(bc, v.Address) == nil {
+			t.Fatalf("%s: validator key missing: %v", v.Name, err)
+		}
+		hashes = append(hashes, bc.GenesisHash())
+	}
+	if hashes[0] != hashes[1] || hashes[1] != hashes[2] {
+		t.Fatal("nodes disagree on the genesis block")
+	}
+
+	if _, err := InitNetwork(InitOptions{Dir: dir, ChainID: "again", Validators: 1}); err == nil {
+		t.Fatal("init overwrote an existing network")
+	}
+
+	// A node directory left over from another network keeps its key.
+	other := t.TempDir()
+	wallets := filepath.Join(other, "node2", WalletsFile)
+	os.MkdirAll(filepath.Dir(wallets), 0o755)
+	os.WriteFile(wallets, []byte("[]"), 0o600)
+	if _, err := InitNetwork(InitOptions{Dir: other, ChainID: "other", Validators: 2}); err == nil {
+		t.Fatal("init overwrote a node's wallets")
+	}
+	if _, err := os.Stat(filepath.Join(other, GenesisFile)); err == nil {
+		t.Fatal("init wrote files before refusing")
+	}
+}
+
+func TestImportEnforcesBlockLimits(t *testing.T) {
+	producer := proposeAs(NewBlockchainWithConfig(testSpec()), "validator")
+	importer := proposeAs(NewBlockchainWithConfig(testSpec()), "validator")
+	importer.config.Limits.MaxBlockTxs = 1
+
+	block, err := producer.CommitBlock("two", []Transaction{transfer("alice", "x", 1, 0, 0), transfer("alice", "x", 1, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := importer.ImportBlock(block); !errors.Is(err, ErrInvalidBlock) || !errors.Is(err, ErrTooManyTxs) {
+		t.Fatalf("expected too many transactions, got %v", err)
+	}
+	importer.config.Limits = Limits{MaxBlockBytes: block.Txs[0].Size()}
+	if err := importer.ImportBlock(block); !errors.Is(err, ErrBlockTooLarge) {
+		t.Fatalf("expected a block too large, got %v", err)
+	}
+	if _, err := importer.CommitBlock("two", block.Txs); !errors.Is(err, ErrBlockTooLarge) {
+		t.Fatalf("committed a block over the limit: %v", err)
+	}
+	// Snapshot sync skips executing the block, not its limits.
+	importer.config.Limits = Limits{MaxBlockTxs: 1}
+	if err := importer.ImportSnapshot(producer.BlocksFrom(0), producer.LatestSnapshot()); !errors.Is(err, ErrTooManyTxs) {
+		t.Fatalf("expected too many transactions from the snapshot sync, got %v", err)
+	}
+}
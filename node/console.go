@@ -0,0 +1,474 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ---------------- CONSOLE ----------------
+// Console runs the node's commands. The local REPL, `proco-node attach`
+// (through RPC) and scripts all go through it, so they share one command
+// set and one output format.
+type Console struct {
+	bc      *Blockchain
+	mp      *Mempool
+	network *Network // nil when the node runs without p2p
+}
+
+func NewConsole(bc *Blockchain, mp *Mempool, network *Network) *Console {
+	return &Console{bc: bc, mp: mp, network: network}
+}
+
+// CommandResult is the output of a command: text for people and a value
+// for --json and scripts.
+type CommandResult struct {
+	Text  string      `json:"text"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Command is one console command.
+type Command struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage"` // arguments, e.g. "<address> [height]"
+	run   func(c *Console, args []string) (*CommandResult, error)
+}
+
+// ErrUnknownCommand is returned by Exec for a name not in Commands.
+var ErrUnknownCommand = errors.New("unknown command, type 'help' for commands")
+
+// Commands lists the console commands in help order.
+var Commands []Command
+
+func init() {
+	Commands = []Command{
+		{"help", "", (*Console).help},
+		{"show_chain", "", (*Console).showChain},
+		{"height", "", (*Console).height},
+		{"add_block", "<data>", (*Console).addBlock},
+		{"validate", "", (*Console).validate},
+		{"verify_chain", "[--full] [--json]", (*Console).verifyChain},
+		{"genesis", "", (*Console).genesis},
+		{"create_wallet", "<initial_balance>", (*Console).createWallet},
+		{"list_wallets", "", (*Console).listWallets},
+		{"send", "<from_address> <to_address> <amount> [fee]", (*Console).send},
+		{"mempool", "", (*Console).mempool},
+		{"template", "", (*Console).template},
+		{"proposer", "[wallet_address]", (*Console).proposer},
+		{"validators", "", (*Console).validators},
+		{"balance", "<wallet_address>", (*Console).balance},
+		{"proof", "<wallet_address> [height]", (*Console).proof},
+		{"peers", "", (*Console).peers},
+		{"snapshot", "", (*Console).snapshot},
+		{"snapshot_sync", "<peer_addr>", (*Console).snapshotSync},
+		{"reindex", "", (*Console).reindex},
+		{"fault", "[list | add <action> [peer=<addr>] [type=<TYPE>] [prob=<0..1>] [ms=<n>] | remove <id> | clear]", (*Console).fault},
+		{"partition", "[<peer_addr>... | heal]", (*Console).partition},
+	}
+}
+
+// Exec runs one command line.
+func (c *Console) Exec(line string) (*CommandResult, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return &CommandResult{}, nil
+	}
+	for _, cmd := range Commands {
+		if cmd.Name == fields[0] {
+			return cmd.run(c, fields[1:])
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCommand, fields[0])
+}
+
+func usageError(name string) error {
+	for _, cmd := range Commands {
+		if cmd.Name == name {
+			return fmt.Errorf("usage: %s %s", name, cmd.Usage)
+		}
+	}
+	return fmt.Errorf("usage: %s", name)
+}
+
+func textf(value interface{}, format string, args ...interface{}) *CommandResult {
+	return &CommandResult{Text: fmt.Sprintf(format, args...), Value: value}
+}
+
+func jsonText(v interface{}) string {
+	out, _ := json.MarshalIndent(v, "", "  ")
+	return string(out)
+}
+
+// ---------------- COMMANDS ----------------
+func (c *Console) help(args []string) (*CommandResult, error) {
+	var b strings.Builder
+	b.WriteString("\nAvailable commands:\n")
+	for _, cmd := range Commands {
+		fmt.Fprintf(&b, " %s %s\n", cmd.Name, cmd.Usage)
+	}
+	b.WriteString(" exit")
+	return &CommandResult{Text: b.String(), Value: Commands}, nil
+}
+
+func (c *Console) showChain(args []string) (*CommandResult, error) {
+	blocks := c.bc.BlocksFrom(0)
+	return textf(blocks, "\n📦 Blockchain:\n%s", jsonText(blocks)), nil
+}
+
+func (c *Console) height(args []string) (*CommandResult, error) {
+	h := c.bc.Height()
+	return textf(h, "%d", h), nil
+}
+
+func (c *Console) addBlock(args []string) (*CommandResult, error) {
+	if len(args) == 0 {
+		return nil, usageError("add_block")
+	}
+	block, err := c.bc.CommitBlock(strings.Join(args, " "), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.announce(block)
+	return textf(block, "✅ Block added and blockchain saved."), nil
+}
+
+// announce gossips a block made here, and its transactions, to the peers.
+func (c *Console) announce(block Block) {
+	if c.network == nil {
+		return
+	}
+	for _, tx := range block.Txs {
+		c.network.BroadcastTx(tx)
+	}
+	c.network.BroadcastBlock(block)
+}
+
+func (c *Console) validate(args []string) (*CommandResult, error) {
+	var out bytes.Buffer
+	ok := c.bc.ValidateChain(&out)
+	return textf(ok, "%s", strings.TrimRight(out.String(), "\n")), nil
+}
+
+func (c *Console) verifyChain(args []string) (*CommandResult, error) {
+	full, asJSON := false, false
+	for _, a := range args {
+		switch a {
+		case "--full":
+			full = true
+		case "--json":
+			asJSON = true
+		default:
+			return nil, usageError("verify_chain")
+		}
+	}
+	report := c.bc.VerifyChain(full)
+	if asJSON {
+		return &CommandResult{Text: jsonText(report), Value: report}, nil
+	}
+	var out bytes.Buffer
+	report.WriteText(&out)
+	return textf(report, "%s", strings.TrimRight(out.String(), "\n")), nil
+}
+
+func (c *Console) genesis(args []string) (*CommandResult, error) {
+	cfg := c.bc.Config()
+	info := map[string]interface{}{
+		"chain_id":   cfg.ChainID,
+		"hash":       c.bc.GenesisHash(),
+		"validators": len(cfg.Validators),
+	}
+	return textf(info, "Chain ID    : %s\nGenesis hash: %s\nValidators  : %d",
+		cfg.ChainID, info["hash"], len(cfg.Validators)), nil
+}
+
+func (c *Console) createWallet(args []string) (*CommandResult, error) {
+	if len(args) != 1 {
+		return nil, usageError("create_wallet")
+	}
+	balance, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, errors.New("enter a valid number for balance")
+	}
+	wallet, err := NewWallet(c.bc, c.mp, balance)
+	if err != nil {
+		return nil, fmt.Errorf("could not fund wallet: %w", err)
+	}
+	if err := c.bc.AddWallet(wallet); err != nil {
+		return nil, fmt.Errorf("saving wallet: %w", err)
+	}
+	return textf(wallet.Address, "\n✅ Wallet created successfully!\n Address : %s\n Balance : %d",
+		wallet.Address, GetBalance(c.bc, wallet.Address)), nil
+}
+
+// walletInfo is a wallet as listed by the console. Keys are never shown.
+type walletInfo struct {
+	Address string `json:"address"`
+	Balance int    `json:"balance"`
+}
+
+func (c *Console) listWallets(args []string) (*CommandResult, error) {
+	wallets := c.bc.LocalWallets()
+	if len(wallets) == 0 {
+		return textf([]walletInfo{}, "No wallets found."), nil
+	}
+	var b strings.Builder
+	b.WriteString("\n💼 Wallets:")
+	list := make([]walletInfo, len(wallets))
+	for i, w := range wallets {
+		list[i] = walletInfo{w.Address, GetBalance(c.bc, w.Address)}
+		fmt.Fprintf(&b, "\n%d) %s | Balance: %d", i+1, w.Address, list[i].Balance)
+	}
+	return &CommandResult{Text: b.String(), Value: list}, nil
+}
+
+func (c *Console) send(args []string) (*CommandResult, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return nil, usageError("send")
+	}
+	amount, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, errors.New("invalid amount")
+	}
+	fee := c.bc.Config().MinTxFee
+	if len(args) == 4 {
+		if fee, err = strconv.Atoi(args[3]); err != nil {
+			return nil, errors.New("invalid fee")
+		}
+	}
+	block, err := SendCoins(c.bc, c.mp, args[0], args[1], amount, fee)
+	if err != nil {
+		return nil, fmt.Errorf("transaction rejected: %w", err)
+	}
+	c.announce(block)
+	return textf(block, "✅ Transaction successful and saved to blockchain (block %d, %d tx).",
+		block.Index, len(block.Txs)), nil
+}
+
+func (c *Console) proposer(args []string) (*CommandResult, error) {
+	if len(args) == 1 {
+		c.bc.SetProposer(args[0])
+	}
+	p := c.bc.Proposer()
+	if p == "" {
+		return textf(p, "No proposer set: fees of blocks produced here are burned."), nil
+	}
+	return textf(p, "⛏️ Block fees go to %s", p), nil
+}
+
+func (c *Console) validators(args []string) (*CommandResult, error) {
+	vals := c.bc.Validators()
+	evidence := c.bc.PendingEvidence()
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n🛡️ Validators: %d", len(vals))
+	for _, v := range vals {
+		status := "active"
+		switch {
+		case v.Jailed:
+			status = fmt.Sprintf("jailed until epoch %d for a double sign at block %d", v.Jail.Until, v.Jail.Height)
+		case v.Jail != nil:
+			status = fmt.Sprintf("active, released after a double sign at block %d", v.Jail.Height)
+		}
+		fmt.Fprintf(&b, "\n %-8s %s | %s", v.Name, v.Address, status)
+	}
+	next := c.bc.ProposerAt(c.bc.Clock().Now())
+	if next != "" {
+		fmt.Fprintf(&b, "\nSlot proposer now: %s", next)
+	}
+	for _, e := range evidence {
+		fmt.Fprintf(&b, "\n⚠️ Pending evidence: %s signed blocks %s and %s at height %d", e.Validator(), e.A.Hash, e.B.Hash, e.Height())
+	}
+	value := map[string]interface{}{"validators": vals, "proposer": next, "evidence": evidence}
+	return &CommandResult{Text: b.String(), Value: value}, nil
+}
+
+func (c *Console) mempool(args []string) (*CommandResult, error) {
+	pending, future := c.mp.Pending(), c.mp.Future()
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n⏳ Mempool: %d pending, %d future", len(pending), len(future))
+	for _, tx := range pending {
+		fmt.Fprintf(&b, "\n pending %s -> %s | %d ProCo | fee %d | nonce %d", tx.From, tx.To, tx.Amount, tx.Fee, tx.Nonce)
+	}
+	for _, tx := range future {
+		fmt.Fprintf(&b, "\n future  %s -> %s | %d ProCo | fee %d | nonce %d", tx.From, tx.To, tx.Amount, tx.Fee, tx.Nonce)
+	}
+	value := map[string][]Transaction{"pending": pending, "future": future}
+	return &CommandResult{Text: b.String(), Value: value}, nil
+}
+
+func (c *Console) template(args []string) (*CommandResult, error) {
+	limits := c.bc.Config().BlockLimits()
+	tmpl := c.mp.Template(limits)
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n🧱 Next block: %d txs, %d/%d bytes", len(tmpl.Txs), tmpl.Bytes, limits.MaxBlockBytes)
+	if limits.MaxBlockTxs > 0 {
+		fmt.Fprintf(&b, ", tx limit %d", limits.MaxBlockTxs)
+	}
+	for _, tx := range tmpl.Txs {
+		fmt.Fprintf(&b, "\n include %s | fee %d | nonce %d | %d bytes", tx.Hash(), tx.Fee, tx.Nonce, tx.Size())
+	}
+	for _, s := range tmpl.Skipped {
+		fmt.Fprintf(&b, "\n skip    %s | fee %d | nonce %d | %d bytes | %s", s.Hash, s.Fee, s.Nonce, s.Size, s.Reason)
+		if s.Detail != "" {
+			fmt.Fprintf(&b, ": %s", s.Detail)
+		}
+	}
+	return &CommandResult{Text: b.String(), Value: tmpl}, nil
+}
+
+func (c *Console) balance(args []string) (*CommandResult, error) {
+	if len(args) != 1 {
+		return nil, usageError("balance")
+	}
+	balance := GetBalance(c.bc, args[0])
+	return textf(balance, "💰 Wallet %s Balance: %d", args[0], balance), nil
+}
+
+func (c *Console) proof(args []string) (*CommandResult, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, usageError("proof")
+	}
+	height := -1
+	if len(args) == 2 {
+		h, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, errors.New("invalid height")
+		}
+		height = h
+	}
+	proof, err := c.bc.GetProof(args[0], height)
+	if err != nil {
+		return nil, err
+	}
+	text := jsonText(proof)
+	if err := VerifyAccountProof(proof.StateRoot, proof); err != nil {
+		text += "\n❌ Proof does not verify: " + err.Error()
+	} else {
+		text += fmt.Sprintf("\n✅ Proof verifies against state root of block %d", proof.Height)
+	}
+	return &CommandResult{Text: text, Value: proof}, nil
+}
+
+func (c *Console) peers(args []string) (*CommandResult, error) {
+	if c.network == nil {
+		return nil, errors.New("networking is not enabled on this node")
+	}
+	peers := c.network.Peers()
+	if len(peers) == 0 {
+		return textf(peers, "No peers configured."), nil
+	}
+	var b strings.Builder
+	b.WriteString("🔗 Peers:")
+	for _, p := range peers {
+		st, ok := c.network.PeerStatus(p)
+		if !ok {
+			fmt.Fprintf(&b, "\n %s | not heard from", p)
+			continue
+		}
+		fmt.Fprintf(&b, "\n %s | height %d | head %.12s", p, st.Height, st.Head)
+	}
+	return &CommandResult{Text: b.String(), Value: peers}, nil
+}
+
+func (c *Console) snapshot(args []string) (*CommandResult, error) {
+	snap, err := c.bc.TakeSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot failed: %w", err)
+	}
+	return textf(snap.Height, "📸 Snapshot written at block %d (state root %s)", snap.Height, snap.StateRoot), nil
+}
+
+func (c *Console) reindex(args []string) (*CommandResult, error) {
+	n, err := c.bc.Reindex()
+	if err != nil {
+		return nil, fmt.Errorf("reindex failed: %w", err)
+	}
+	kinds := c.bc.Indexes()
+	if len(kinds) == 0 {
+		return textf(kinds, "No indexes enabled."), nil
+	}
+	return textf(kinds, "🗂️ Reindexed %d blocks (%s)", n, strings.Join(kinds, ", ")), nil
+}
+
+func (c *Console) snapshotSync(args []string) (*CommandResult, error) {
+	if len(args) != 1 {
+		return nil, usageError("snapshot_sync")
+	}
+	if c.network == nil {
+		return nil, errors.New("networking is not enabled on this node")
+	}
+	if err := c.network.SnapshotSync(args[0]); err != nil {
+		return nil, fmt.Errorf("snapshot sync failed: %w", err)
+	}
+	h := c.bc.Height()
+	return textf(h, "✅ Synced from %s - now at block %d", args[0], h), nil
+}
+
+// fault manages the fault injection rules of the transport.
+func (c *Console) fault(args []string) (*CommandResult, error) {
+	if c.network == nil {
+		return nil, errors.New("networking is not enabled on this node")
+	}
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "list":
+		rules := c.network.Faults()
+		if len(rules) == 0 {
+			return textf(rules, "No fault rules. Actions: %s", strings.Join(FaultActions, ", ")), nil
+		}
+		var b strings.Builder
+		b.WriteString("💥 Fault rules:")
+		for _, r := range rules {
+			fmt.Fprintf(&b, "\n %s", r)
+		}
+		return &CommandResult{Text: b.String(), Value: rules}, nil
+	case "add":
+		rule, err := ParseFaultRule(args[1:])
+		if err == nil {
+			rule, err = c.network.AddFault(rule)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return textf(rule, "💥 Added %s", rule), nil
+	case "remove":
+		if len(args) != 2 {
+			return nil, usageError("fault")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			return nil, usageError("fault")
+		}
+		if err := c.network.RemoveFault(id); err != nil {
+			return nil, err
+		}
+		return textf(id, "Removed fault rule #%d", id), nil
+	case "clear":
+		c.network.ClearFaults()
+		return textf(nil, "All fault rules removed."), nil
+	}
+	return nil, usageError("fault")
+}
+
+// partition cuts this node off from the given peers, or shows or heals
+// the current partition.
+func (c *Console) partition(args []string) (*CommandResult, error) {
+	if c.network == nil {
+		return nil, errors.New("networking is not enabled on this node")
+	}
+	switch {
+	case len(args) == 1 && args[0] == "heal":
+		c.network.SetPartition(nil)
+	case len(args) > 0:
+		c.network.SetPartition(args)
+	}
+	cut := c.network.Partition()
+	if len(cut) == 0 {
+		return textf(cut, "Not partitioned."), nil
+	}
+	return textf(cut, "✂️ Cut off from %s", strings.Join(cut, ", ")), nil
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConsoleCommands(t *testing.T) {
+	c := newScriptConsole(t)
+	bc := c.bc
+
+	res, err := c.Exec("create_wallet 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w1, ok := res.Value.(string)
+	if !ok || FindWallet(bc, w1) == nil {
+		t.Fatalf("create_wallet returned %v", res.Value)
+	}
+	res, _ = c.Exec("create_wallet 0")
+	w2 := res.Value.(string)
+	if _, err := c.Exec("create_wallet 5000"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("funded a wallet beyond the genesis alloc: %v", err)
+	}
+	if bc.State().Balance(addr("faucet")) != 900 {
+		t.Fatalf("faucet holds %d", bc.State().Balance(addr("faucet")))
+	}
+
+	if _, err := c.Exec("send " + w1 + " " + w2 + " 10 0"); err != nil {
+		t.Fatal(err)
+	}
+	res, err = c.Exec("balance " + w1)
+	if err != nil || res.Value != 90 || !strings.Contains(res.Text, "90") {
+		t.Fatalf("balance: %+v %v", res, err)
+	}
+
+	res, _ = c.Exec("validate")
+	if res.Value != true {
+		t.Fatalf("validate: %s", res.Text)
+	}
+
+	if _, err := c.Exec("send " + w1); err == nil || !strings.HasPrefix(err.Error(), "usage: send") {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+	if _, err := c.Exec("frobnicate"); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("expected unknown command, got %v", err)
+	}
+	if _, err := c.Exec("peers"); err == nil {
+		t.Fatal("peers without a network should fail")
+	}
+}
+
+func TestCreateWalletWhileSigning(t *testing.T) {
+	c := newScriptConsole(t)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if _, err := c.Exec("create_wallet 0"); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if FindWallet(c.bc, addr("faucet")) == nil {
+			t.Fatal("lost the faucet wallet")
+		}
+	}
+	<-done
+
+	saved := NewBlockchain()
+	if err := saved.LoadWallets(c.bc.walletsFile()); err != nil || len(saved.LocalWallets()) != 21 {
+		t.Fatalf("saved %d wallets: %v", len(saved.LocalWallets()), err)
+	}
+}
+
+func TestCreateWalletAfterPendingTransfer(t *testing.T) {
+	c := newScriptConsole(t)
+	if err := c.mp.Add(transfer("faucet", "alice", 5, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Exec("create_wallet 10")
+	if err != nil {
+		t.Fatalf("funding did not queue behind the pending transfer: %v", err)
+	}
+	state := c.bc.State()
+	if state.Balance(res.Value.(string)) != 10 || state.Balance(addr("alice")) != 5 || c.mp.Size() != 0 {
+		t.Fatalf("block %d did not confirm both transfers", c.bc.Height())
+	}
+}
+
+func TestWalletsFileIsPrivate(t *testing.T) {
+	c := newScriptConsole(t)
+	path := c.bc.walletsFile()
+	if err := os.WriteFile(path, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Exec("create_wallet 0"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("%s holds private keys with mode %o", WalletsFile, perm)
+	}
+}
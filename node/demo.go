@@ -0,0 +1,325 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ---------------- TAMPER DEMO ----------------
+// The tamper demo shows why blocks cannot be edited quietly. It copies a
+// chain into a sandbox, edits one block of the copy the way someone would
+// edit blocks.json by hand, verifies the copy, lets a new node try to sync
+// from it, and finally restores the copy from an honest peer. Nothing
+// outside the sandbox is touched.
+
+// Ways of tampering with a block.
+const (
+	TamperTx     = "tx"     // raise the amount of a signed transaction
+	TamperData   = "data"   // change the block's data, keeping its hash
+	TamperRehash = "rehash" // change the data and recompute the hash
+)
+
+// TamperDemo configures RunTamperDemo.
+type TamperDemo struct {
+	Spec   *Config // genesis spec of the chain; nil means DefaultConfig
+	Source string  // chain file to copy; empty or missing builds a small chain
+	Dir    string  // sandbox directory
+	Block  int     // block to modify; 0 picks one in the middle
+	Mode   string  // TamperTx, TamperData or TamperRehash; empty picks one
+	Pause  func()  // called between steps, e.g. to wait for Enter; may be nil
+}
+
+// demoSettle is how long heights must stay put before a sync counts as done.
+const demoSettle = 500 * time.Millisecond
+
+type tamperDemo struct {
+	TamperDemo
+	w    io.Writer
+	step int
+	nets []*Network
+}
+
+// RunTamperDemo runs the demo and narrates it to w.
+func RunTamperDemo(d TamperDemo, w io.Writer) error {
+	if d.Spec == nil {
+		d.Spec = DefaultConfig()
+	}
+	demo := &tamperDemo{TamperDemo: d, w: w}
+	defer demo.stop()
+	return demo.run()
+}
+
+func (d *tamperDemo) say(format string, args ...interface{}) {
+	fmt.Fprintf(d.w, format+"\n", args...)
+}
+
+func (d *tamperDemo) next(title string) {
+	if d.Pause != nil && d.step > 0 {
+		d.Pause()
+	}
+	d.step++
+	d.say("\n━━ Step %d/5: %s ━━", d.step, title)
+}
+
+func (d *tamperDemo) run() error {
+	honestPath := filepath.Join(d.Dir, "honest", ChainFile)
+	tamperedPath := filepath.Join(d.Dir, "tampered", ChainFile)
+
+	d.next("copy the chain into the sandbox")
+	honest, err := d.copyChain(honestPath)
+	if err != nil {
+		return err
+	}
+	if err := copyFile(honestPath, tamperedPath); err != nil {
+		return err
+	}
+	d.say("📁 Sandbox: %s", d.Dir)
+	d.say("The honest copy has %d blocks; head %.16s", honest.Height()+1, honest.Head().Hash)
+
+	d.next("tamper with a block")
+	height, err := d.tamper(tamperedPath)
+	if err != nil {
+		return err
+	}
+
+	d.next("validate the tampered copy")
+	tampered, err := LoadBlockchain(tamperedPath, d.Spec)
+	if err != nil {
+		return err
+	}
+	report := tampered.VerifyChain(true)
+	report.WriteText(d.w)
+
+	d.next("sync a new node from the tampered copy")
+	honestNet, err := d.start(honest, nil)
+	if err != nil {
+		return err
+	}
+	tamperedNet, err := d.start(tampered, nil)
+	if err != nil {
+		return err
+	}
+	newcomer := NewBlockchainWithConfig(d.Spec)
+	if _, err := d.start(newcomer, []string{tamperedNet.listenAddr}); err != nil {
+		return err
+	}
+	d.say("A fresh node joins and asks the tampered copy for its blocks...")
+	settle(newcomer)
+	if newcomer.Height() < tampered.Height() {
+		d.say("❌ The newcomer rejected block %d and stopped at block %d: it checks every block it is sent,", newcomer.Height()+1, newcomer.Height())
+		d.say("   so the altered chain cannot spread.")
+	} else {
+		d.say("The newcomer took all %d blocks: on its own the altered copy is consistent.", newcomer.Height()+1)
+	}
+	if newcomer.Head().Hash != honest.BlocksFrom(newcomer.Height())[0].Hash {
+		d.say("   Its head already differs from the honest chain's block %d, which peers will overrule.", newcomer.Height())
+	}
+
+	d.next("restore from a peer")
+	restoreFrom := height - 1
+	if report.FirstUntrusted > 0 && report.FirstUntrusted-1 < restoreFrom {
+		restoreFrom = report.FirstUntrusted - 1
+	}
+	dropped, err := tampered.Rewind(restoreFrom)
+	if err != nil {
+		return err
+	}
+	d.say("Dropped %d untrusted block(s); the copy now ends at block %d.", len(dropped), restoreFrom)
+	d.stopNet(tamperedNet)
+	if _, err := d.start(tampered, []string{honestNet.listenAddr}); err != nil {
+		return err
+	}
+	d.say("Syncing the missing blocks from the honest peer...")
+	settle(tampered)
+	if tampered.Head().Hash != honest.Head().Hash {
+		return fmt.Errorf("restore failed: head %s at %d, peer has %s at %d",
+			tampered.Head().Hash, tampered.Height(), honest.Head().Hash, honest.Height())
+	}
+	if r := tampered.VerifyChain(true); !r.Valid {
+		r.WriteText(d.w)
+		return errors.New("restored chain does not verify")
+	}
+	d.say("✅ Restored: %d blocks, head %.16s matches the peer and the chain verifies.", tampered.Height()+1, tampered.Head().Hash)
+	return nil
+}
+
+// copyChain copies the source chain to path. A chain with fewer than two
+// blocks is replaced by a new one, whose spec funds alice at genesis, with
+// a few transfers to tamper with.
+func (d *tamperDemo) copyChain(path string) (*Blockchain, error) {
+	if d.Source != "" {
+		if _, err := os.Stat(d.Source); err == nil {
+			if err := copyFile(d.Source, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	bc, err := LoadBlockchain(path, d.Spec)
+	if err != nil || bc.Height() >= 2 {
+		return bc, err
+	}
+
+	d.say("The chain is too short to tamper with, so the sandbox gets a new chain where alice pays bob a few times.")
+	alice, err := GenerateWallet()
+	if err != nil {
+		return nil, err
+	}
+	bob, err := GenerateWallet()
+	if err != nil {
+		return nil, err
+	}
+	spec := *d.Spec
+	spec.Alloc = append(append([]GenesisAlloc(nil), d.Spec.Alloc...), GenesisAlloc{Address: alice.Address, Balance: 1000})
+	spec.InitialSupply += 1000
+	d.Spec = &spec
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	if bc, err = LoadBlockchain(path, d.Spec); err != nil {
+		return nil, err
+	}
+	for i := 0; i < 4; i++ {
+		tx := Transaction{To: bob.Address, Amount: 10 * (i + 1), Fee: d.Spec.MinTxFee, Nonce: uint64(i)}
+		if err := alice.SignTx(&tx, d.Spec.ChainID); err != nil {
+			return nil, err
+		}
+		if _, err := bc.CommitBlock(fmt.Sprintf("alice pays bob %d", tx.Amount), []Transaction{tx}); err != nil {
+			return nil, err
+		}
+	}
+	return bc, nil
+}
+
+// tamper edits one block in the chain file at path, like an edit by hand,
+// and returns its height.
+func (d *tamperDemo) tamper(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var file struct{ Blocks []Block }
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, err
+	}
+	head := len(file.Blocks) - 1
+	height := d.Block
+	if height == 0 {
+		height = (head + 1) / 2
+	}
+	if height < 1 || height > head {
+		return 0, fmt.Errorf("block %d is not between 1 and the head %d", height, head)
+	}
+	block := &file.Blocks[height]
+
+	signed := -1
+	for i, tx := range block.Txs {
+		if tx.From != "" && signed < 0 {
+			signed = i
+		}
+	}
+	mode := d.Mode
+	if mode == "" {
+		mode = TamperData
+		if signed >= 0 {
+			mode = TamperTx
+		}
+	}
+
+	d.say("✏️  Editing block %d of %s:", height, path)
+	switch mode {
+	case TamperTx:
+		if signed < 0 {
+			return 0, fmt.Errorf("block %d has no signed transaction", height)
+		}
+		tx := &block.Txs[signed]
+		d.say("  - Txs[%d].Amount: %d", signed, tx.Amount)
+		tx.Amount *= 10
+		d.say("  + Txs[%d].Amount: %d", signed, tx.Amount)
+		d.say("Nothing else changes: the signature, transaction root and header hash still cover the old amount.")
+	case TamperData, TamperRehash:
+		d.say("  - Data: %q", block.Data)
+		block.Data += " (tampered)"
+		d.say("  + Data: %q", block.Data)
+		if mode == TamperRehash {
+			if height == head {
+				return 0, fmt.Errorf("rehashing the head leaves no later block to catch it; pick a block below %d", head)
+			}
+			d.say("  - Hash: %s", block.Hash)
+			block.Hash = CalculateHash(*block)
+			d.say("  + Hash: %s", block.Hash)
+			d.say("The block's own hash is recomputed, but block %d still points at the old one.", height+1)
+		}
+	default:
+		return 0, fmt.Errorf("unknown tamper mode %q (have %s, %s, %s)", mode, TamperTx, TamperData, TamperRehash)
+	}
+	if err := writeJSONFile(path, file, 0o644); err != nil {
+		return 0, err
+	}
+	// The index belongs to the original chain.
+	os.Remove(filepath.Join(filepath.Dir(path), IndexFile))
+	return height, nil
+}
+
+// start runs a p2p node for bc on a free local port.
+func (d *tamperDemo) start(bc *Blockchain, peers []string) (*Network, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	n := NewNetwork(addr, bc, NewMempool(bc, DefaultMempoolConfig), peers)
+	if err := n.Start(); err != nil {
+		return nil, err
+	}
+	d.nets = append(d.nets, n)
+	return n, nil
+}
+
+func (d *tamperDemo) stopNet(n *Network) {
+	for i, m := range d.nets {
+		if m == n {
+			d.nets = append(d.nets[:i], d.nets[i+1:]...)
+			n.Stop()
+			return
+		}
+	}
+}
+
+func (d *tamperDemo) stop() {
+	for _, n := range d.nets {
+		n.Stop()
+	}
+}
+
+// settle waits until the height of bc stops changing.
+func settle(bc *Blockchain) {
+	height, since := bc.Height(), time.Now()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if h := bc.Height(); h != height {
+			height, since = h, time.Now()
+		} else if time.Since(since) >= demoSettle {
+			return
+		}
+	}
+}
+
+func copyFile(from, to string) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(to, data, 0o644)
+}
@@ -0,0 +1,22 @@
+package node
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTamperDemo(t *testing.T) {
+	for _, mode := range []string{TamperTx, TamperRehash} {
+		var out bytes.Buffer
+		err := RunTamperDemo(TamperDemo{Dir: t.TempDir(), Block: 2, Mode: mode}, &out)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", mode, err, out.String())
+		}
+		for _, want := range []string{"First untrusted block: 2", "The newcomer rejected block", "✅ Restored"} {
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("%s: no %q in\n%s", mode, want, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ---------------- VALIDATION ERRORS ----------------
+// Every rejected transaction fails with one of these, wrapped in a
+// TxError. Match them with errors.Is.
+var (
+	ErrMalformedTx         = errors.New("malformed transaction")
+	ErrBadSignature        = errors.New("invalid signature")
+	ErrWrongChain          = errors.New("transaction is for another chain")
+	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrOverflow            = errors.New("amount overflow")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrNonceTooLow         = errors.New("nonce already used")
+	ErrNonceTooHigh        = errors.New("nonce too far ahead of account")
+	ErrFeeTooLow           = errors.New("fee below chain minimum")
+	ErrTxTooLarge          = errors.New("transaction too large")
+	ErrSelfTransfer        = errors.New("sender and receiver are the same")
+)
+
+// ---------------- MEMPOOL ERRORS ----------------
+var (
+	ErrAlreadyKnown       = errors.New("transaction already in mempool")
+	ErrReplaceUnderpriced = errors.New("replacement fee too low")
+	ErrAccountLimit       = errors.New("too many transactions from sender")
+	ErrMempoolFull        = errors.New("mempool full and fee too low to evict")
+	ErrNotPoolable        = errors.New("transaction needs a sender")
+	ErrTxExpired          = errors.New("transaction expired")
+	ErrUnknownWallet      = errors.New("wallet not found")
+)
+
+// ErrNotFound is returned by lookups of blocks and transactions.
+var ErrNotFound = errors.New("not found")
+
+// ErrGenesisMismatch means chain data belongs to another network.
+var ErrGenesisMismatch = errors.New("genesis block does not match")
+
+// ErrPartitioned is returned when sending to a peer we are cut off from.
+var ErrPartitioned = errors.New("partitioned from peer")
+
+// ErrDropped is returned for a message a fault rule dropped.
+var ErrDropped = errors.New("message dropped by fault rule")
+
+// ErrBadFault is returned for an invalid fault rule.
+var ErrBadFault = errors.New("invalid fault rule")
+
+// ---------------- BLOCK IMPORT ERRORS ----------------
+var (
+	ErrKnownBlock    = errors.New("block already known")
+	ErrFutureBlock   = errors.New("block is ahead of our head")
+	ErrUnknownParent = errors.New("block's parent is not our head")
+	ErrShorterFork   = errors.New("block is on a fork no longer than our chain")
+	ErrReorgTooDeep  = errors.New("reorg would drop too many blocks")
+	ErrInvalidBlock  = errors.New("invalid block")
+	ErrBlockTooLarge = errors.New("block transactions exceed max_block_bytes")
+	ErrTooManyTxs    = errors.New("block has more transactions than max_block_txs")
+)
+
+// ---------------- TIMESTAMP ERRORS ----------------
+// A block with a bad timestamp fails with one of these, wrapped in a
+// TimestampError.
+var (
+	ErrTimestampTooOld = errors.New("timestamp not after the median of recent blocks")
+	ErrTimestampFuture = errors.New("timestamp too far in the future")
+	ErrTimestampSlot   = errors.New("timestamp not on a slot boundary")
+)
+
+// ---------------- CONSENSUS ERRORS ----------------
+var (
+	ErrBlockSignature  = errors.New("block not signed by its validator")
+	ErrBadEvidence     = errors.New("invalid double-sign evidence")
+	ErrKnownEvidence   = errors.New("double sign already reported")
+	ErrValidatorJailed = errors.New("validator is jailed")
+	ErrWrongProposer   = errors.New("block not from the proposer of its slot")
+)
+
+// ---------------- TX ERROR ----------------
+// TxError says which rule rejected which transaction.
+type TxError struct {
+	Hash string
+	Rule string
+	Err  error
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("tx %s: %s: %v", e.Hash, e.Rule, e.Err)
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
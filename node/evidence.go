@@ -0,0 +1,367 @@
+package node
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ---------------- DOUBLE-SIGN EVIDENCE ----------------
+// On a chain with validators, every block comes from the validator whose
+// slot it is in and carries its signature over its hash. An honest validator
+// never signs two different blocks at one height, so two such headers
+// prove misbehaviour to anyone who knows the validator set. A node that
+// sees a pair keeps it as Evidence, gossips it and puts it in the next
+// block it makes. Applying that block jails the validator: for the next
+// jail_epochs epochs its blocks are invalid and the proposer rotation
+// skips it. A chain without epoch_duration_sec never releases it.
+
+// Evidence is two conflicting headers signed by the same validator. A has
+// the smaller hash, so a pair has one form only.
+type Evidence struct {
+	A Header `json:"A"`
+	B Header `json:"B"`
+}
+
+// NewEvidence puts two conflicting headers in order.
+func NewEvidence(a, b Header) Evidence {
+	if b.Hash < a.Hash {
+		a, b = b, a
+	}
+	return Evidence{A: a, B: b}
+}
+
+// Validator is the address that signed both headers.
+func (e Evidence) Validator() string {
+	return e.A.Proposer
+}
+
+// Height is where the validator signed twice.
+func (e Evidence) Height() int {
+	return e.A.Index
+}
+
+func (e Evidence) Hash() string {
+	record := fmt.Sprintf("%s|%s|%s|%s", e.A.Hash, e.A.Signature, e.B.Hash, e.B.Signature)
+	h := sha256.Sum256([]byte(record))
+	return hex.EncodeToString(h[:])
+}
+
+// EvidenceRoot is the hash of the evidence hashes of a block, or "" for
+// a block without evidence.
+func EvidenceRoot(evidence []Evidence) string {
+	if len(evidence) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, e := range evidence {
+		h.Write([]byte(e.Hash()))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyEvidence checks that e holds two different headers of one height,
+// both correctly hashed and signed by the same validator of cfg.
+func VerifyEvidence(cfg *Config, e Evidence) error {
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrBadEvidence, fmt.Sprintf(format, args...))
+	}
+	a, b := e.A, e.B
+	switch {
+	case a.Index != b.Index:
+		return fail("headers at heights %d and %d", a.Index, b.Index)
+	case a.Proposer != b.Proposer:
+		return fail("headers proposed by %s and %s", a.Proposer, b.Proposer)
+	case a.Hash >= b.Hash:
+		return fail("headers are not two different blocks in hash order")
+	}
+	v, ok := cfg.validator(a.Proposer)
+	if !ok {
+		return fail("%s is not a validator", a.Proposer)
+	}
+	for _, h := range []Header{a, b} {
+		if h.CalculateHash() != h.Hash {
+			return fail("header %s does not match its hash", h.Hash)
+		}
+		if err := verifyHeaderSignature(v, h); err != nil {
+			return fail("%v", err)
+		}
+	}
+	return nil
+}
+
+// ---------------- BLOCK SIGNATURES ----------------
+// blockSigningPayload is what a validator signs for a block.
+func blockSigningPayload(hash string) []byte {
+	return []byte("block|" + hash)
+}
+
+func verifyHeaderSignature(v GenesisValidator, h Header) error {
+	pub, err := hex.DecodeString(v.PubKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: validator %s has a malformed key", ErrBlockSignature, v.Address)
+	}
+	sig, err := hex.DecodeString(h.Signature)
+	if err != nil || !ed25519.Verify(pub, blockSigningPayload(h.Hash), sig) {
+		return fmt.Errorf("%w: block %d by %s", ErrBlockSignature, h.Index, v.Address)
+	}
+	return nil
+}
+
+// validator returns the validator with the given address.
+func (c *Config) validator(address string) (GenesisValidator, bool) {
+	for _, v := range c.Validators {
+		if v.Address == address && address != "" {
+			return v, true
+		}
+	}
+	return GenesisValidator{}, false
+}
+
+// checkSignature checks that a block of a chain with validators is
+// signed by its proposer, which must be one of them.
+func (bc *Blockchain) checkSignature(block Block) error {
+	if len(bc.config.Validators) == 0 {
+		return nil
+	}
+	v, ok := bc.config.validator(block.Proposer)
+	if !ok {
+		return fmt.Errorf("%w: block %d proposer %q is not a validator", ErrBlockSignature, block.Index, block.Proposer)
+	}
+	return verifyHeaderSignature(v, block.Header())
+}
+
+// sign signs a block made here with the proposer's key from the local
+// wallets. On a chain with validators a block cannot go unsigned, so it
+// fails when the proposer is not a validator or its key is missing. The
+// caller holds bc.mu.
+func (bc *Blockchain) sign(block *Block) error {
+	if len(bc.config.Validators) == 0 {
+		return nil
+	}
+	if _, ok := bc.config.validator(block.Proposer); !ok {
+		return fmt.Errorf("%w: proposer %q is not a validator", ErrBlockSignature, block.Proposer)
+	}
+	w := FindWallet(bc, block.Proposer)
+	if w == nil {
+		return fmt.Errorf("%w: no key for validator %s in the wallets", ErrBlockSignature, block.Proposer)
+	}
+	return w.SignBlock(block)
+}
+
+// ---------------- JAILS ----------------
+// Jail is the last punishment of a validator. It stays in the state after
+// the validator is released, so the same double sign cannot be punished
+// twice.
+type Jail struct {
+	Height int `json:"Height"` // where the validator signed twice
+	Until  int `json:"Until"`  // first epoch it may propose in again
+}
+
+// encode is the value stored in the state tree for a jail.
+func (j Jail) encode() []byte {
+	return []byte(fmt.Sprintf("jail|%d|%d", j.Height, j.Until))
+}
+
+func jailKey(address string) [32]byte {
+	return sha256.Sum256([]byte("jail/" + address))
+}
+
+func (s *State) jail(address string, j *Jail) {
+	if s.Jails == nil {
+		s.Jails = make(map[string]*Jail)
+	}
+	s.Jails[address] = j
+}
+
+// Jailed reports whether address may not propose in epoch.
+func (s *State) Jailed(address string, epoch int) bool {
+	j := s.Jails[address]
+	return j != nil && epoch < j.Until
+}
+
+// epochAt is the number of the epoch t falls in, counted from genesis.
+func (c *Config) epochAt(t time.Time) int {
+	if c.Consensus.EpochDurationSec <= 0 {
+		return 0
+	}
+	return int(t.Sub(c.Timestamp) / (time.Duration(c.Consensus.EpochDurationSec) * time.Second))
+}
+
+func (c *Config) jailEpochs() int {
+	if c.Consensus.JailEpochs > 0 {
+		return c.Consensus.JailEpochs
+	}
+	return DefaultJailEpochs
+}
+
+// checkEvidence checks e and that its double sign is not punished in
+// state yet.
+func (bc *Blockchain) checkEvidence(state *State, e Evidence) error {
+	if err := VerifyEvidence(bc.config, e); err != nil {
+		return err
+	}
+	if j := state.Jails[e.Validator()]; j != nil && j.Height >= e.Height() {
+		return fmt.Errorf("%w: %s at height %d", ErrKnownEvidence, e.Validator(), e.Height())
+	}
+	return nil
+}
+
+// punishDoubleSigns is an EndBlockHook: a block may not come from a jailed
+// validator, and its evidence jails the validators it names.
+func (bc *Blockchain) punishDoubleSigns(state *State, block Block) error {
+	epoch := bc.config.epochAt(block.Timestamp.Time)
+	if state.Jailed(block.Proposer, epoch) {
+		return fmt.Errorf("%w: %s is out until epoch %d, block %d is in epoch %d",
+			ErrValidatorJailed, block.Proposer, state.Jails[block.Proposer].Until, block.Index, epoch)
+	}
+	for _, e := range block.Evidence {
+		if err := bc.checkEvidence(state, e); err != nil {
+			return err
+		}
+		state.jail(e.Validator(), bc.config.jailFor(e, block))
+	}
+	return nil
+}
+
+// jailFor is the jail that evidence e in block puts its validator in.
+func (c *Config) jailFor(e Evidence, block Block) *Jail {
+	return &Jail{Height: e.Height(), Until: c.epochAt(block.Timestamp.Time) + 1 + c.jailEpochs()}
+}
+
+// ---------------- EVIDENCE POOL ----------------
+// AddEvidence keeps e for the next block made here. It fails for invalid
+// evidence and for double signs already pending or punished.
+func (bc *Blockchain) AddEvidence(e Evidence) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.addEvidence(e)
+}
+
+func (bc *Blockchain) addEvidence(e Evidence) error {
+	if err := bc.checkEvidence(bc.state, e); err != nil {
+		return err
+	}
+	for _, p := range bc.evidence {
+		if p.Validator() == e.Validator() && p.Height() == e.Height() {
+			return fmt.Errorf("%w: %s at height %d", ErrKnownEvidence, e.Validator(), e.Height())
+		}
+	}
+	bc.evidence = append(bc.evidence, e)
+	bc.metrics.DoubleSigns.Inc()
+	bc.logs.consensus.Warn("double sign detected", "validator", e.Validator(), "height", e.Height(),
+		"hashes", e.A.Hash+","+e.B.Hash)
+	return nil
+}
+
+// ReportConflict compares block with ours at the same height. If one
+// validator signed both, the pair becomes pending evidence and is
+// returned, to be gossiped.
+func (bc *Blockchain) ReportConflict(block Block) (Evidence, bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if block.Index < 1 || block.Index >= len(bc.Blocks) {
+		return Evidence{}, false
+	}
+	ours := bc.Blocks[block.Index]
+	if ours.Hash == block.Hash || ours.Proposer != block.Proposer {
+		return Evidence{}, false
+	}
+	e := NewEvidence(ours.Header(), block.Header())
+	if bc.addEvidence(e) != nil {
+		return Evidence{}, false
+	}
+	return e, true
+}
+
+// PendingEvidence returns the evidence waiting for a block.
+func (bc *Blockchain) PendingEvidence() []Evidence {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]Evidence(nil), bc.evidence...)
+}
+
+// pruneEvidence drops pending evidence that state has punished. The
+// caller holds bc.mu.
+func (bc *Blockchain) pruneEvidence(state *State) {
+	kept := bc.evidence[:0]
+	for _, e := range bc.evidence {
+		if bc.checkEvidence(state, e) == nil {
+			kept = append(kept, e)
+		}
+	}
+	bc.evidence = kept
+}
+
+// ---------------- PROPOSER ROTATION ----------------
+// ValidatorStatus is a validator with its jail, if it has one.
+type ValidatorStatus struct {
+	GenesisValidator
+	Jail   *Jail `json:"jail,omitempty"`
+	Jailed bool  `json:"jailed"` // in the current epoch
+}
+
+// Validators returns the validator set in genesis order as of the head,
+// at the time of the chain's clock.
+func (bc *Blockchain) Validators() []ValidatorStatus {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	epoch := bc.config.epochAt(bc.clock.Now())
+	out := make([]ValidatorStatus, len(bc.config.Validators))
+	for i, v := range bc.config.Validators {
+		out[i] = ValidatorStatus{GenesisValidator: v, Jail: bc.state.Jails[v.Address], Jailed: bc.state.Jailed(v.Address, epoch)}
+	}
+	return out
+}
+
+// ProposerAt is the validator whose slot t falls in. Slots go round the
+// validators that are not jailed, in genesis order. It is "" when the
+// chain has no slots or every validator is jailed.
+func (bc *Blockchain) ProposerAt(t time.Time) string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.proposerAt(bc.state, t)
+}
+
+// proposerAt is ProposerAt with the jails of state.
+func (bc *Blockchain) proposerAt(state *State, t time.Time) string {
+	slot := bc.config.slotDuration()
+	if slot == 0 {
+		return ""
+	}
+	epoch := bc.config.epochAt(t)
+	var active []string
+	for _, v := range bc.config.Validators {
+		if !state.Jailed(v.Address, epoch) {
+			active = append(active, v.Address)
+		}
+	}
+	if len(active) == 0 {
+		return ""
+	}
+	return active[int(t.Sub(bc.config.Timestamp)/slot)%len(active)]
+}
+
+// checkSlot checks that a block of a chain with validators, made on top of
+// state, comes from the validator whose slot it is in. Without slots any
+// validator that is not jailed may propose.
+func (bc *Blockchain) checkSlot(state *State, block Block) error {
+	if len(bc.config.Validators) == 0 {
+		return nil
+	}
+	if _, ok := bc.config.validator(block.Proposer); !ok {
+		return fmt.Errorf("%w: block %d proposer %q is not a validator", ErrWrongProposer, block.Index, block.Proposer)
+	}
+	t := block.Timestamp.Time
+	if j := state.Jails[block.Proposer]; state.Jailed(block.Proposer, bc.config.epochAt(t)) {
+		return fmt.Errorf("%w: %s is out until epoch %d, block %d is in epoch %d",
+			ErrValidatorJailed, block.Proposer, j.Until, block.Index, bc.config.epochAt(t))
+	}
+	if want := bc.proposerAt(state, t); want != "" && want != block.Proposer {
+		return fmt.Errorf("%w: block %d at %s is in the slot of %s, not %s",
+			ErrWrongProposer, block.Index, block.Timestamp, want, block.Proposer)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// poaSpec is a chain run by validators v1, v2 and v3 with one-minute
+// epochs.
+func poaSpec() *Config {
+	cfg := DefaultConfig()
+	cfg.Consensus = ConsensusParams{SlotDurationSec: 5, EpochDurationSec: 60, JailEpochs: 1}
+	for _, name := range []string{"v1", "v2", "v3"} {
+		w := testWallet(name)
+		cfg.Validators = append(cfg.Validators, GenesisValidator{Name: name, Address: w.Address, PubKey: w.PublicKey})
+	}
+	return cfg
+}
+
+// proposeAs makes bc propose its blocks as the wallet name and sign them
+// with its key.
+func proposeAs(bc *Blockchain, name string) *Blockchain {
+	bc.Wallets = []*Wallet{testWallet(name)}
+	bc.SetProposer(addr(name))
+	return bc
+}
+
+// startValidator starts a node of poaSpec that proposes as validator.
+func startValidator(t *testing.T, clock Clock, validator, addr string, peers ...string) *Network {
+	t.Helper()
+	bc := NewBlockchainWithConfig(poaSpec())
+	bc.SetClock(clock)
+	proposeAs(bc, validator)
+	n := NewNetwork(addr, bc, NewMempool(bc, DefaultMempoolConfig), peers)
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(n.Stop)
+	return n
+}
+
+func TestDoubleSignIsPunished(t *testing.T) {
+	clock := NewSimClock(simStart)
+	addrA, addrB, addrC := freeAddr(t), freeAddr(t), freeAddr(t)
+	a := startValidator(t, clock, "v1", addrA, addrB, addrC)
+	b := startValidator(t, clock, "v2", addrB, addrA, addrC)
+	c := startValidator(t, clock, "v3", addrC, addrA, addrB)
+
+	block, err := a.bc.CommitBlock("one", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.BroadcastBlock(block)
+	waitFor(t, "B and C to import block 1", func() bool { return b.bc.Height() == 1 && c.bc.Height() == 1 })
+
+	// v1 signs a second block at height 1 on the side and sends it out.
+	side := proposeAs(NewBlockchainWithConfig(poaSpec()), "v1")
+	side.SetClock(clock)
+	fork, err := side.CommitBlock("other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.BroadcastBlock(fork)
+	waitFor(t, "the evidence to spread", func() bool {
+		return len(a.bc.PendingEvidence()) == 1 && len(c.bc.PendingEvidence()) == 1
+	})
+	ev := b.bc.PendingEvidence()[0]
+	if ev.Validator() != addr("v1") || ev.Height() != 1 {
+		t.Fatalf("evidence against %s at %d", ev.Validator(), ev.Height())
+	}
+
+	clock.Advance(5 * time.Second)
+	punish, err := b.bc.CommitBlock("punish", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(punish.Evidence) != 1 {
+		t.Fatal("the evidence was not included")
+	}
+	b.BroadcastBlock(punish)
+	waitFor(t, "A and C to import the evidence", func() bool { return a.bc.Height() == 2 && c.bc.Height() == 2 })
+	for _, n := range []*Network{a, b, c} {
+		if !n.bc.State().Jailed(addr("v1"), poaSpec().epochAt(clock.Now())) || len(n.bc.PendingEvidence()) != 0 {
+			t.Fatalf("%s did not jail v1", n.listenAddr)
+		}
+		if n.bc.Head().StateRoot != punish.StateRoot {
+			t.Fatal("the jail is not in the state root")
+		}
+	}
+	if err := a.bc.AddEvidence(ev); !errors.Is(err, ErrKnownEvidence) {
+		t.Fatalf("punished the same double sign again: %v", err)
+	}
+
+	// Jailed, v1 is out of the rotation and its blocks are refused.
+	for i := 0; i < 3; i++ {
+		if p := a.bc.ProposerAt(clock.Now().Add(time.Duration(i*5) * time.Second)); p == addr("v1") {
+			t.Fatal("a jailed validator is in the rotation")
+		}
+	}
+	clock.Advance(5 * time.Second)
+	if _, err := a.bc.CommitBlock("jailed", nil); !errors.Is(err, ErrValidatorJailed) {
+		t.Fatalf("a jailed validator proposed a block: %v", err)
+	}
+
+	// The jail ends with the epoch after next.
+	clock.Advance(2 * time.Minute)
+	if _, err := a.bc.CommitBlock("released", nil); err != nil {
+		t.Fatalf("released validator could not propose: %v", err)
+	}
+}
+
+func TestEvidenceMustBeSigned(t *testing.T) {
+	cfg := poaSpec()
+	x, y := NewBlockchainWithConfig(cfg), NewBlockchainWithConfig(cfg)
+	for _, bc := range []*Blockchain{x, y} {
+		proposeAs(bc, "v1")
+	}
+	bx, _ := x.CommitBlock("x", nil)
+	by, _ := y.CommitBlock("y", nil)
+	e := NewEvidence(bx.Header(), by.Header())
+	if err := VerifyEvidence(cfg, e); err != nil {
+		t.Fatal(err)
+	}
+
+	forged := e
+	forged.B.Signature = forged.A.Signature
+	if err := VerifyEvidence(cfg, forged); !errors.Is(err, ErrBadEvidence) {
+		t.Fatalf("accepted a forged signature: %v", err)
+	}
+	forged = NewEvidence(bx.Header(), bx.Header())
+	if err := VerifyEvidence(cfg, forged); !errors.Is(err, ErrBadEvidence) {
+		t.Fatalf("accepted one block twice: %v", err)
+	}
+
+	// An unsigned block of a validator is not imported.
+	unsigned := by
+	unsigned.Signature = ""
+	if err := NewBlockchainWithConfig(cfg).ImportBlock(unsigned); !errors.Is(err, ErrBlockSignature) {
+		t.Fatalf("imported an unsigned block: %v", err)
+	}
+}
+
+func TestImportNeedsTheSlotProposer(t *testing.T) {
+	clock := NewSimClock(simStart)
+	x := proposeAs(NewBlockchainWithConfig(poaSpec()), "v1")
+	y := proposeAs(NewBlockchainWithConfig(poaSpec()), "v1")
+	x.SetClock(clock)
+	y.SetClock(clock)
+	one, err := x.CommitBlock("x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork, err := y.CommitBlock("y", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := x.AddEvidence(NewEvidence(one.Header(), fork.Header())); err != nil {
+		t.Fatal(err)
+	}
+	two, err := proposeAs(x, "v2").CommitBlock("punish", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// forge returns block as proposed by proposer and signed with the key
+	// of signer, if any. Its state root is that of x, for a snapshot.
+	forge := func(block Block, proposer, signer string) Block {
+		block.Proposer, block.Signature, block.StateRoot = proposer, "", x.State().Root()
+		block.Hash = CalculateHash(block)
+		if signer != "" {
+			testWallet(signer).SignBlock(&block)
+		}
+		return block
+	}
+	next := two
+	next.Index, next.PrevHash, next.Data, next.Evidence = 3, two.Hash, "jailed", nil
+	next.Timestamp = NewBlockTime(two.Timestamp.Add(5 * time.Second))
+
+	genesis := x.Blocks[0]
+	for name, c := range map[string]struct {
+		blocks []Block
+		want   error
+	}{
+		"unsigned":     {[]Block{genesis, forge(one, "", "")}, ErrBlockSignature},
+		"signed by v1": {[]Block{genesis, forge(one, addr("v2"), "v1")}, ErrBlockSignature},
+		"out of slot":  {[]Block{genesis, forge(one, addr("v2"), "v2")}, ErrWrongProposer},
+		"jailed":       {[]Block{genesis, one, two, forge(next, addr("v1"), "v1")}, ErrValidatorJailed},
+	} {
+		last := c.blocks[len(c.blocks)-1]
+		importer := NewBlockchainWithConfig(poaSpec())
+		importer.SetClock(clock)
+		var err error
+		for _, block := range c.blocks[1:] {
+			if err = importer.ImportBlock(block); err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, ErrInvalidBlock) || !errors.Is(err, c.want) {
+			t.Fatalf("%s: expected %v from ImportBlock, got %v", name, c.want, err)
+		}
+
+		// Snapshot sync checks blocks up to the snapshot it does not execute.
+		fresh := NewBlockchainWithConfig(poaSpec())
+		fresh.SetClock(clock)
+		if err := fresh.ImportSnapshot(c.blocks, newSnapshot(last, x.State())); !errors.Is(err, c.want) {
+			t.Fatalf("%s: expected %v from ImportSnapshot, got %v", name, c.want, err)
+		}
+	}
+}
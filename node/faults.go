@@ -0,0 +1,340 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------- FAULT INJECTION ----------------
+// Fault rules make the transport misbehave on purpose, to show latency,
+// forks and recovery. A rule matches outgoing messages by peer and type
+// and, with its probability, applies one action:
+//
+//	delay      send the message DelayMs late
+//	drop       do not send it; the connection is closed instead
+//	duplicate  send it a second time on a new connection
+//	reorder    hold it for a random time up to DelayMs, so later ones overtake it
+//	corrupt    change one character of its body, like a bit flip on the wire
+//
+// Replies are messages too, so a dropped BLOCKS reply fails the sync that
+// asked for it. Replies are never duplicated: the peer would not expect
+// them on a connection of their own.
+
+const (
+	FaultDelay     = "delay"
+	FaultDrop      = "drop"
+	FaultDuplicate = "duplicate"
+	FaultReorder   = "reorder"
+	FaultCorrupt   = "corrupt"
+)
+
+// FaultActions lists the fault actions in help order.
+var FaultActions = []string{FaultDelay, FaultDrop, FaultDuplicate, FaultReorder, FaultCorrupt}
+
+// DefaultReorderMs is the window of a reorder rule without DelayMs.
+const DefaultReorderMs = 500
+
+// FaultRule is one fault injection rule.
+type FaultRule struct {
+	ID      int     `json:"id"`
+	Action  string  `json:"action"`
+	Peer    string  `json:"peer,omitempty"`     // P2P address; empty matches every peer
+	Type    string  `json:"type,omitempty"`     // message type; empty matches every type
+	Prob    float64 `json:"prob,omitempty"`     // chance per message; 0 means always
+	DelayMs int     `json:"delay_ms,omitempty"` // for delay and reorder
+	Hits    int     `json:"hits"`               // messages it applied to
+}
+
+func (r FaultRule) String() string {
+	s := fmt.Sprintf("#%d %s", r.ID, r.Action)
+	if r.Action == FaultDelay || r.Action == FaultReorder {
+		s += fmt.Sprintf(" %dms", r.DelayMs)
+	}
+	peer, typ := r.Peer, r.Type
+	if peer == "" {
+		peer = "all peers"
+	}
+	if typ == "" {
+		typ = "all messages"
+	}
+	s += fmt.Sprintf(" | %s | %s", typ, peer)
+	if r.Prob < 1 {
+		s += fmt.Sprintf(" | p=%g", r.Prob)
+	}
+	return s + fmt.Sprintf(" | %d hits", r.Hits)
+}
+
+func (r *FaultRule) matches(peer, msgType string) bool {
+	return (r.Peer == "" || r.Peer == peer) && (r.Type == "" || strings.EqualFold(r.Type, msgType))
+}
+
+// ParseFaultRule parses the console form of a rule:
+// "<action> [peer=<addr>] [type=<TYPE>] [prob=<0..1>] [ms=<delay>]".
+func ParseFaultRule(args []string) (FaultRule, error) {
+	if len(args) == 0 {
+		return FaultRule{}, fmt.Errorf("%w: missing action", ErrBadFault)
+	}
+	rule := FaultRule{Action: args[0]}
+	for _, arg := range args[1:] {
+		key, value, ok := strings.Cut(arg, "=")
+		var err error
+		switch {
+		case !ok:
+			err = fmt.Errorf("want key=value")
+		case key == "peer":
+			rule.Peer = value
+		case key == "type":
+			rule.Type = strings.ToUpper(value)
+		case key == "prob":
+			rule.Prob, err = strconv.ParseFloat(value, 64)
+		case key == "ms":
+			rule.DelayMs, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return FaultRule{}, fmt.Errorf("%w: %s: %v", ErrBadFault, arg, err)
+		}
+	}
+	return rule, nil
+}
+
+// faultPlan is what the rules decided for one message.
+type faultPlan struct {
+	drop, duplicate, corrupt bool
+	delay                    time.Duration
+	applied                  []string // actions, for logs and events
+}
+
+type faults struct {
+	mu     sync.Mutex
+	rules  []*FaultRule
+	nextID int
+	rand   *rand.Rand // seeded with the first rule
+}
+
+// plan rolls every rule matching a message to peer.
+func (f *faults) plan(peer, msgType string) faultPlan {
+	var p faultPlan
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.rules {
+		if !r.matches(peer, msgType) || f.rand.Float64() >= r.Prob {
+			continue
+		}
+		switch r.Action {
+		case FaultDelay:
+			p.delay += time.Duration(r.DelayMs) * time.Millisecond
+		case FaultReorder:
+			p.delay += time.Duration(f.rand.Intn(r.DelayMs+1)) * time.Millisecond
+		case FaultDrop:
+			p.drop = true
+		case FaultDuplicate:
+			if isReply(msgType) {
+				continue
+			}
+			p.duplicate = true
+		case FaultCorrupt:
+			p.corrupt = true
+		}
+		r.Hits++
+		p.applied = append(p.applied, r.Action)
+	}
+	return p
+}
+
+// corrupt returns a copy of msg with one digit or hex letter of a value
+// in its body replaced by another, so the message still parses and it is
+// the hashes and signatures that catch the change.
+func (f *faults) corrupt(msg *NetMessage) *NetMessage {
+	dec := json.NewDecoder(bytes.NewReader(msg.Body))
+	dec.UseNumber()
+	var body interface{}
+	if dec.Decode(&body) != nil {
+		return msg
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n := countCorruptible(body); n > 0 {
+		target := f.rand.Intn(n)
+		body = f.corruptValue(body, &target)
+	}
+	out := *msg
+	out.Body, _ = json.Marshal(body)
+	return &out
+}
+
+// corruptible reports whether a string has a character corrupt can change.
+func corruptible(s string) bool {
+	return strings.ContainsAny(s, "0123456789abcdef")
+}
+
+func countCorruptible(v interface{}) int {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n := 0
+		for _, e := range v {
+			n += countCorruptible(e)
+		}
+		return n
+	case []interface{}:
+		n := 0
+		for _, e := range v {
+			n += countCorruptible(e)
+		}
+		return n
+	case string:
+		if corruptible(v) {
+			return 1
+		}
+	case json.Number:
+		return 1
+	}
+	return 0
+}
+
+// corruptValue changes the target-th corruptible value in v, counting
+// down target as it walks. Map keys are visited in sorted order so a
+// seeded rand picks the same value every time.
+func (f *faults) corruptValue(v interface{}, target *int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v[k] = f.corruptValue(v[k], target)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = f.corruptValue(v[i], target)
+		}
+	case string:
+		if corruptible(v) {
+			*target--
+			if *target == -1 {
+				return f.flip(v)
+			}
+		}
+	case json.Number:
+		*target--
+		if *target == -1 {
+			return json.Number(f.flip(string(v)))
+		}
+	}
+	return v
+}
+
+// flip replaces one digit or hex letter of s by another of the same kind.
+func (f *faults) flip(s string) string {
+	b := []byte(s)
+	var spots []int
+	for i, c := range b {
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'f' {
+			spots = append(spots, i)
+		}
+	}
+	i := spots[f.rand.Intn(len(spots))]
+	alphabet := "0123456789"
+	if b[i] >= 'a' {
+		alphabet = "abcdef"
+	}
+	for was := b[i]; b[i] == was; {
+		b[i] = alphabet[f.rand.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// isReply reports whether a message type only answers a request.
+func isReply(msgType string) bool {
+	switch msgType {
+	case MsgTypePeerList, MsgTypeBlocks, MsgTypeSnapshot, MsgTypeError:
+		return true
+	}
+	return false
+}
+
+// AddFault validates and adds a fault rule, returning it with its ID.
+func (n *Network) AddFault(rule FaultRule) (FaultRule, error) {
+	valid := false
+	for _, a := range FaultActions {
+		valid = valid || rule.Action == a
+	}
+	switch {
+	case !valid:
+		return rule, fmt.Errorf("%w: unknown action %q, want one of %s", ErrBadFault, rule.Action, strings.Join(FaultActions, ", "))
+	case rule.Prob < 0 || rule.Prob > 1:
+		return rule, fmt.Errorf("%w: prob must be between 0 and 1", ErrBadFault)
+	case rule.DelayMs < 0:
+		return rule, fmt.Errorf("%w: negative delay", ErrBadFault)
+	case rule.Action == FaultDelay && rule.DelayMs == 0:
+		return rule, fmt.Errorf("%w: a delay needs ms", ErrBadFault)
+	}
+	if rule.Prob == 0 {
+		rule.Prob = 1
+	}
+	if rule.Action == FaultReorder && rule.DelayMs == 0 {
+		rule.DelayMs = DefaultReorderMs
+	}
+	rule.Type = strings.ToUpper(rule.Type)
+	rule.Hits = 0
+
+	now := n.bc.Clock().Now()
+	n.faults.mu.Lock()
+	if n.faults.rand == nil {
+		// Seeded from the node's clock, so a simulated clock repeats the
+		// same faults.
+		n.faults.rand = rand.New(rand.NewSource(now.UnixNano()))
+	}
+	rule.ID = n.faults.nextID
+	n.faults.nextID++
+	r := rule
+	n.faults.rules = append(n.faults.rules, &r)
+	n.faults.mu.Unlock()
+	n.bc.logs.p2p.Info("fault rule added", "rule", rule.String())
+	return rule, nil
+}
+
+// RemoveFault removes the rule with the given ID.
+func (n *Network) RemoveFault(id int) error {
+	n.faults.mu.Lock()
+	defer n.faults.mu.Unlock()
+	for i, r := range n.faults.rules {
+		if r.ID == id {
+			n.faults.rules = append(n.faults.rules[:i], n.faults.rules[i+1:]...)
+			n.bc.logs.p2p.Info("fault rule removed", "rule", r.String())
+			return nil
+		}
+	}
+	return fmt.Errorf("fault rule %d: %w", id, ErrNotFound)
+}
+
+// ClearFaults removes every fault rule.
+func (n *Network) ClearFaults() {
+	n.faults.mu.Lock()
+	defer n.faults.mu.Unlock()
+	if len(n.faults.rules) > 0 {
+		n.bc.logs.p2p.Info("fault rules cleared", "count", len(n.faults.rules))
+	}
+	n.faults.rules = nil
+}
+
+// Faults lists the fault rules in the order they were added.
+func (n *Network) Faults() []FaultRule {
+	n.faults.mu.Lock()
+	defer n.faults.mu.Unlock()
+	out := make([]FaultRule, len(n.faults.rules))
+	for i, r := range n.faults.rules {
+		out[i] = *r
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// faultPair starts A and B, peered both ways, and returns them once A
+// knows B.
+func faultPair(t *testing.T) (a, b *Network) {
+	t.Helper()
+	addrA, addrB := freeAddr(t), freeAddr(t)
+	a = startNetwork(t, addrA)
+	b = startNetwork(t, addrB, addrA)
+	waitFor(t, "A to learn about B", func() bool { return len(a.Peers()) == 1 })
+	return a, b
+}
+
+func TestFaultDropAndCorrupt(t *testing.T) {
+	a, b := faultPair(t)
+	drop, err := a.AddFault(FaultRule{Action: FaultDrop, Peer: b.listenAddr, Type: "block"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := a.bc.CommitBlock("one", nil)
+	if err := a.send(b.listenAddr, a.message(MsgTypeBlock, block)); !errors.Is(err, ErrDropped) {
+		t.Fatalf("send of a dropped message: %v", err)
+	}
+	if rules := a.Faults(); len(rules) != 1 || rules[0].Hits != 1 {
+		t.Fatalf("rules after one drop: %+v", rules)
+	}
+
+	a.RemoveFault(drop.ID)
+	a.BroadcastBlock(block)
+	waitFor(t, "B to import the block", func() bool { return b.bc.Head().Hash == block.Hash })
+
+	// A corrupted transaction fails B's signature check.
+	tx := transfer("alice", "bob", 1, 1, 0)
+	a.AddFault(FaultRule{Action: FaultCorrupt, Type: MsgTypeTx})
+	a.send(b.listenAddr, a.message(MsgTypeTx, tx))
+	time.Sleep(100 * time.Millisecond)
+	if len(b.mempool.Pending()) != 0 {
+		t.Fatal("B accepted a corrupted transaction")
+	}
+	a.ClearFaults()
+	a.send(b.listenAddr, a.message(MsgTypeTx, tx))
+	waitFor(t, "B to accept the transaction", func() bool { return len(b.mempool.Pending()) == 1 })
+}
+
+func TestFaultDelayAndDuplicate(t *testing.T) {
+	a, b := faultPair(t)
+	events, stop := b.Watch()
+	defer stop()
+	a.AddFault(FaultRule{Action: FaultDelay, Type: MsgTypeTx, DelayMs: 200})
+	a.AddFault(FaultRule{Action: FaultDuplicate, Type: MsgTypeTx})
+
+	start := time.Now()
+	if err := a.send(b.listenAddr, a.message(MsgTypeTx, transfer("alice", "bob", 1, 1, 0))); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 200*time.Millisecond {
+		t.Fatal("the message was not delayed")
+	}
+	received := 0
+	waitFor(t, "B to receive the copy", func() bool {
+		for len(events) > 0 {
+			if ev := <-events; ev.Dir == "recv" && ev.Type == MsgTypeTx {
+				received++
+			}
+		}
+		return received == 2
+	})
+}
+
+func TestFaultRuleValidation(t *testing.T) {
+	n := startNetwork(t, freeAddr(t))
+	for _, args := range [][]string{
+		{"explode"},
+		{"drop", "prob=2"},
+		{"delay"},
+		{"drop", "peer"},
+		{"drop", "color=red"},
+	} {
+		rule, err := ParseFaultRule(args)
+		if err == nil {
+			_, err = n.AddFault(rule)
+		}
+		if !errors.Is(err, ErrBadFault) {
+			t.Errorf("%v: got %v, want ErrBadFault", args, err)
+		}
+	}
+
+	rule, err := ParseFaultRule([]string{"reorder", "type=block", "prob=0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, err = n.AddFault(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.ID != 1 || rule.Type != MsgTypeBlock || rule.Prob != 0.5 || rule.DelayMs != DefaultReorderMs {
+		t.Fatalf("added rule: %+v", rule)
+	}
+	if err := n.RemoveFault(7); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("removing an unknown rule: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------- INDEXES ----------------
+// Indexes answer lookups without scanning the chain:
+//
+//	tx       tx hash -> block height and position
+//	address  address -> the transactions it sent or received
+//	block    block hash -> height
+//
+// They are updated when blocks are appended, rolled back when a reorg
+// drops blocks, and stored in index.json next to the chain file. An index
+// file that does not match the chain head is rebuilt on load; `reindex`
+// rebuilds it on demand. Lookups fall back to scanning when their index
+// is disabled.
+
+const (
+	IndexTx      = "tx"
+	IndexAddress = "address"
+	IndexBlock   = "block"
+)
+
+// AllIndexes lists every index kind.
+var AllIndexes = []string{IndexTx, IndexAddress, IndexBlock}
+
+// IndexFile is the name of the index file in a data directory.
+const IndexFile = "index.json"
+
+// ErrBadCursor is returned for a pagination cursor that was not issued by
+// this node.
+var ErrBadCursor = errors.New("invalid cursor")
+
+// TxRef is the position of a transaction in the chain.
+type TxRef struct {
+	Height int `json:"h"`
+	Index  int `json:"i"`
+}
+
+// chainIndex holds the enabled indexes; the maps of disabled ones are nil.
+type chainIndex struct {
+	Kinds     []string           `json:"kinds"`
+	Head      string             `json:"head"` // hash of the last indexed block
+	Txs       map[string]TxRef   `json:"txs,omitempty"`
+	Addresses map[string][]TxRef `json:"addresses,omitempty"` // oldest first
+	Blocks    map[string]int     `json:"blocks,omitempty"`
+}
+
+func newChainIndex(kinds []string) *chainIndex {
+	ix := &chainIndex{Kinds: append([]string{}, kinds...)}
+	sort.Strings(ix.Kinds)
+	for _, k := range kinds {
+		switch k {
+		case IndexTx:
+			ix.Txs = map[string]TxRef{}
+		case IndexAddress:
+			ix.Addresses = map[string][]TxRef{}
+		case IndexBlock:
+			ix.Blocks = map[string]int{}
+		}
+	}
+	return ix
+}
+
+// ParseIndexes checks a list of index kinds.
+func ParseIndexes(kinds []string) error {
+	for _, k := range kinds {
+		known := false
+		for _, a := range AllIndexes {
+			known = known || a == k
+		}
+		if !known {
+			return fmt.Errorf("unknown index %q (have %s)", k, strings.Join(AllIndexes, ", "))
+		}
+	}
+	return nil
+}
+
+func (ix *chainIndex) add(block Block) {
+	if ix.Blocks != nil {
+		ix.Blocks[block.Hash] = block.Index
+	}
+	for i, tx := range block.Txs {
+		ref := TxRef{block.Index, i}
+		if ix.Txs != nil {
+			if _, ok := ix.Txs[tx.Hash()]; !ok {
+				ix.Txs[tx.Hash()] = ref
+			}
+		}
+		if ix.Addresses != nil {
+			if tx.From != "" {
+				ix.Addresses[tx.From] = append(ix.Addresses[tx.From], ref)
+			}
+			if tx.To != tx.From {
+				ix.Addresses[tx.To] = append(ix.Addresses[tx.To], ref)
+			}
+		}
+	}
+	ix.Head = block.Hash
+}
+
+// remove undoes add for the newest indexed block; parent becomes the head.
+func (ix *chainIndex) remove(block Block, parent string) {
+	if ix.Blocks != nil {
+		delete(ix.Blocks, block.Hash)
+	}
+	for _, tx := range block.Txs {
+		if ix.Txs != nil && ix.Txs[tx.Hash()].Height == block.Index {
+			delete(ix.Txs, tx.Hash())
+		}
+		if ix.Addresses != nil {
+			for _, a := range []string{tx.From, tx.To} {
+				refs := ix.Addresses[a]
+				for len(refs) > 0 && refs[len(refs)-1].Height == block.Index {
+					refs = refs[:len(refs)-1]
+				}
+				if len(refs) == 0 {
+					delete(ix.Addresses, a)
+				} else {
+					ix.Addresses[a] = refs
+				}
+			}
+		}
+	}
+	ix.Head = parent
+}
+
+func (ix *chainIndex) has(kind string) bool {
+	for _, k := range ix.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------- INDEX FILE ----------------
+func (bc *Blockchain) indexPath() string {
+	if bc.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(bc.path), IndexFile)
+}
+
+func (bc *Blockchain) saveIndex() error {
+	path := bc.indexPath()
+	if path == "" {
+		return nil
+	}
+	b, err := json.Marshal(bc.index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// loadIndex reads the index file, or rebuilds the indexes when the file
+// is missing, of other kinds or behind the chain.
+func (bc *Blockchain) loadIndex(kinds []string) {
+	want := newChainIndex(kinds)
+	head := bc.Blocks[len(bc.Blocks)-1].Hash
+	if path := bc.indexPath(); path != "" {
+		var ix chainIndex
+		if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &ix) == nil &&
+			ix.Head == head && strings.Join(ix.Kinds, ",") == strings.Join(want.Kinds, ",") {
+			// Empty maps are left out of the file.
+			if ix.Txs == nil {
+				ix.Txs = want.Txs
+			}
+			if ix.Addresses == nil {
+				ix.Addresses = want.Addresses
+			}
+			if ix.Blocks == nil {
+				ix.Blocks = want.Blocks
+			}
+			bc.index = &ix
+			return
+		}
+	}
+	if err := bc.rebuildIndex(kinds); err != nil {
+		bc.logs.chain.Error("saving index failed", "err", err)
+	}
+}
+
+func (bc *Blockchain) rebuildIndex(kinds []string) error {
+	bc.index = newChainIndex(kinds)
+	for _, b := range bc.Blocks {
+		bc.index.add(b)
+	}
+	bc.logs.chain.Info("indexes rebuilt", "height", len(bc.Blocks)-1, "indexes", strings.Join(bc.index.Kinds, ","))
+	return bc.saveIndex()
+}
+
+// SetIndexes chooses which indexes are kept and builds them.
+func (bc *Blockchain) SetIndexes(kinds []string) error {
+	if err := ParseIndexes(kinds); err != nil {
+		return err
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.loadIndex(kinds)
+	return nil
+}
+
+// Reindex rebuilds the enabled indexes from the blocks and returns how
+// many blocks were indexed.
+func (bc *Blockchain) Reindex() (int, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return len(bc.Blocks), bc.rebuildIndex(bc.index.Kinds)
+}
+
+// Indexes lists the enabled index kinds.
+func (bc *Blockchain) Indexes() []string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]string{}, bc.index.Kinds...)
+}
+
+// ---------------- PAGINATION ----------------
+// Cursors name the last item of a page as "height.index". Pages are
+// newest first, so the next page starts just below the cursor; blocks
+// added meanwhile do not shift it.
+
+func encodeCursor(ref TxRef) string {
+	return fmt.Sprintf("%d.%d", ref.Height, ref.Index)
+}
+
+func decodeCursor(cursor string) (TxRef, error) {
+	h, i, ok := strings.Cut(cursor, ".")
+	height, err1 := strconv.Atoi(h)
+	index, err2 := strconv.Atoi(i)
+	if !ok || err1 != nil || err2 != nil || height < 0 || index < 0 {
+		return TxRef{}, fmt.Errorf("%w: %q", ErrBadCursor, cursor)
+	}
+	return TxRef{height, index}, nil
+}
+
+// before reports whether a comes before b in newest-first order.
+func (a TxRef) before(b TxRef) bool {
+	return a.Height > b.Height || (a.Height == b.Height && a.Index > b.Index)
+}
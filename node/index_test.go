@@ -0,0 +1,117 @@
+package node
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexesFollowReorg(t *testing.T) {
+	a := newTestChain(t, DefaultChainOptions, 3) // alice -> bob three times
+	b, err := LoadBlockchain(filepath.Join(t.TempDir(), "blocks.json"), a.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ImportBlock(a.Blocks[1]); err != nil {
+		t.Fatal(err)
+	}
+	// b forks after block 1: alice pays carol instead, over three blocks.
+	var fork []Block
+	for i := 0; i < 3; i++ {
+		block, err := b.CommitBlock("fork", []Transaction{transfer("alice", "carol", 1, 0, uint64(i+1))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fork = append(fork, block)
+	}
+	dropped := a.Blocks[2]
+
+	if _, err := a.Reorg(fork[:1]); !errors.Is(err, ErrShorterFork) {
+		t.Fatalf("expected ErrShorterFork, got %v", err)
+	}
+	below := fork[0]
+	below.Index = -1
+	for _, bad := range [][]Block{{below}, {fork[0], fork[2]}} {
+		if _, err := a.Reorg(bad); !errors.Is(err, ErrInvalidBlock) {
+			t.Fatalf("expected ErrInvalidBlock, got %v", err)
+		}
+	}
+	removed, err := a.Reorg(b.BlocksFrom(1)) // starts with the shared block
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || removed[0].Hash != dropped.Hash {
+		t.Fatalf("dropped blocks: %+v", removed)
+	}
+	if a.Head().Hash != b.Head().Hash || a.State().Root() != b.State().Root() {
+		t.Fatalf("chain did not switch: head %s, want %s", a.Head().Hash, b.Head().Hash)
+	}
+
+	if _, err := a.TxByHash(dropped.Txs[0].Hash()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("dropped tx still indexed: %v", err)
+	}
+	if _, err := a.BlockByHash(dropped.Hash); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("dropped block still indexed: %v", err)
+	}
+	if loc, err := a.TxByHash(fork[2].Txs[0].Hash()); err != nil || loc.Height != 4 {
+		t.Fatalf("fork tx: %+v, %v", loc, err)
+	}
+	if hist, _ := a.AddressHistory(addr("bob"), "", 0); len(hist.Txs) != 1 {
+		t.Fatalf("bob's history after reorg: %+v", hist.Txs)
+	}
+	if hist, _ := a.AddressHistory(addr("carol"), "", 0); len(hist.Txs) != 3 {
+		t.Fatalf("carol's history after reorg: %+v", hist.Txs)
+	}
+
+	// The saved index matches the new head, and a rebuild agrees with it.
+	loaded, err := LoadBlockchain(a.path, a.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.index.Head != a.Head().Hash || len(loaded.index.Txs) != len(a.index.Txs) {
+		t.Fatalf("loaded index: head %s, %d txs", loaded.index.Head, len(loaded.index.Txs))
+	}
+	if n, err := loaded.Reindex(); err != nil || n != 5 {
+		t.Fatalf("Reindex: %d, %v", n, err)
+	}
+	if len(loaded.index.Addresses[addr("alice")]) != 4 {
+		t.Fatalf("alice after reindex: %+v", loaded.index.Addresses[addr("alice")])
+	}
+}
+
+func TestAddressHistoryPages(t *testing.T) {
+	for _, kinds := range [][]string{AllIndexes, {}} {
+		bc := newTestChain(t, DefaultChainOptions, 8) // alice sends 8 times
+		if err := bc.SetIndexes(kinds); err != nil {
+			t.Fatal(err)
+		}
+
+		var heights []int
+		cursor := ""
+		for pages := 0; ; pages++ {
+			page, err := bc.AddressHistory(addr("alice"), cursor, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, loc := range page.Txs {
+				heights = append(heights, loc.Height)
+			}
+			if page.Next == "" {
+				if pages != 2 {
+					t.Fatalf("indexes %v: expected 3 pages, got %d", kinds, pages+1)
+				}
+				break
+			}
+			cursor = page.Next
+		}
+		if len(heights) != 8 || heights[0] != 8 || heights[7] != 1 {
+			t.Fatalf("indexes %v: heights %v", kinds, heights)
+		}
+		if _, err := bc.AddressHistory(addr("alice"), "x", 3); !errors.Is(err, ErrBadCursor) {
+			t.Fatalf("expected ErrBadCursor, got %v", err)
+		}
+	}
+	if err := NewBlockchain().SetIndexes([]string{"balances"}); err == nil {
+		t.Fatal("expected an error for an unknown index")
+	}
+}
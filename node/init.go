@@ -0,0 +1,129 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ---------------- NETWORK SETUP ----------------
+// InitOptions describes a new network for InitNetwork.
+type InitOptions struct {
+	Dir        string // output directory, one sub-directory per node
+	ChainID    string
+	Validators int
+	// Alloc maps an address, or a node name such as "node1", to its
+	// genesis balance. The initial supply is their sum. Without it the
+	// DevWallet holds the supply of DefaultConfig.
+	Alloc    map[string]int
+	Host     string // interface the nodes listen on
+	P2PPort  int    // port of node1; node i uses P2PPort+i-1
+	RPCPort  int
+	MinTxFee int
+}
+
+// InitNetwork generates a validator key per node, a genesis spec naming
+// them, and a data directory per node holding the spec, the node's key in
+// wallets.json and a node.json that peers it with all the others. It
+// returns the spec. If any of these files exists already, nothing is
+// written.
+func InitNetwork(opts InitOptions) (*Config, error) {
+	if opts.Validators < 1 {
+		return nil, errors.New("need at least one validator")
+	}
+	names := make([]string, opts.Validators)
+	targets := []string{filepath.Join(opts.Dir, GenesisFile)}
+	for i := range names {
+		names[i] = fmt.Sprintf("node%d", i+1)
+		for _, file := range []string{GenesisFile, WalletsFile, NodeConfigFile} {
+			targets = append(targets, filepath.Join(opts.Dir, names[i], file))
+		}
+	}
+	for _, path := range targets {
+		if _, err := os.Lstat(path); err == nil {
+			return nil, fmt.Errorf("%s already exists; init writes nothing over existing files", path)
+		}
+	}
+
+	keys := make([]*Wallet, opts.Validators)
+	spec := DefaultConfig()
+	spec.ChainID = opts.ChainID
+	spec.Timestamp = time.Now().UTC().Truncate(time.Second)
+	spec.MinTxFee = opts.MinTxFee
+	if len(opts.Alloc) > 0 {
+		spec.Alloc, spec.InitialSupply = []GenesisAlloc{}, 0
+	}
+	for i := range keys {
+		w, err := GenerateWallet()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = w
+		spec.Validators = append(spec.Validators, GenesisValidator{Name: names[i], Address: w.Address, PubKey: w.PublicKey})
+	}
+
+	allocs := make([]string, 0, len(opts.Alloc))
+	for a := range opts.Alloc {
+		allocs = append(allocs, a)
+	}
+	sort.Strings(allocs)
+	for _, a := range allocs {
+		address := a
+		for i, name := range names {
+			if a == name {
+				address = keys[i].Address
+			}
+		}
+		spec.Alloc = append(spec.Alloc, GenesisAlloc{Address: address, Balance: opts.Alloc[a]})
+		spec.InitialSupply += opts.Alloc[a]
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := writeJSONFile(filepath.Join(opts.Dir, GenesisFile), spec, 0o644); err != nil {
+		return nil, err
+	}
+
+	p2p := make([]string, len(names))
+	for i := range names {
+		p2p[i] = fmt.Sprintf("%s:%d", opts.Host, opts.P2PPort+i)
+	}
+	for i, name := range names {
+		dir := filepath.Join(opts.Dir, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		if err := writeJSONFile(filepath.Join(dir, GenesisFile), spec, 0o644); err != nil {
+			return nil, err
+		}
+		// The validator key is the node's only wallet at first.
+		if err := writeJSONFile(filepath.Join(dir, WalletsFile), []*Wallet{keys[i]}, 0o600); err != nil {
+			return nil, err
+		}
+		var peers []string
+		for j := range names {
+			if j != i {
+				peers = append(peers, p2p[j])
+			}
+		}
+		cfg := &NodeConfig{
+			Genesis:  GenesisFile,
+			P2PAddr:  p2p[i],
+			RPCAddr:  fmt.Sprintf("%s:%d", opts.Host, opts.RPCPort+i),
+			Peers:    peers,
+			Proposer: keys[i].Address,
+			DataDir:  dir,
+		}
+		if err := cfg.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,181 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ---------------- LOGGING ----------------
+// Every subsystem logs through its own slog.Logger, tagged with a
+// "subsystem" attribute. Levels can be set per subsystem and output is
+// text or JSON. Events use the same keys throughout: height, hash, peer,
+// txid and err.
+
+// Subsystem names.
+const (
+	LogChain     = "chain"
+	LogP2P       = "p2p"
+	LogMempool   = "mempool"
+	LogConsensus = "consensus"
+	LogRPC       = "rpc"
+)
+
+// Subsystems lists every subsystem that logs.
+var Subsystems = []string{LogChain, LogP2P, LogMempool, LogConsensus, LogRPC}
+
+var (
+	chainLog     = Logger(LogChain)
+	p2pLog       = Logger(LogP2P)
+	mempoolLog   = Logger(LogMempool)
+	consensusLog = Logger(LogConsensus)
+)
+
+// nodeLogs are the loggers of one node. Like metrics they belong to the
+// chain; the mempool and the network log through those of their chain.
+type nodeLogs struct {
+	chain, p2p, mempool, consensus *slog.Logger
+}
+
+// newNodeLogs returns the loggers of a node. A name tags every line, so
+// nodes sharing a process can be told apart.
+func newNodeLogs(name string) *nodeLogs {
+	logs := &nodeLogs{chainLog, p2pLog, mempoolLog, consensusLog}
+	if name != "" {
+		logs.chain = logs.chain.With("node", name)
+		logs.p2p = logs.p2p.With("node", name)
+		logs.mempool = logs.mempool.With("node", name)
+		logs.consensus = logs.consensus.With("node", name)
+	}
+	return logs
+}
+
+// SetLogName tags the log lines of this chain, its mempool and its network
+// with name. Call it before the node starts.
+func (bc *Blockchain) SetLogName(name string) {
+	bc.logs = newNodeLogs(name)
+}
+
+// LogConfig selects the output and levels of all loggers.
+type LogConfig struct {
+	Level  slog.Level            // default level
+	Levels map[string]slog.Level // per-subsystem overrides
+	JSON   bool                  // JSON lines instead of key=value text
+	Output io.Writer             // default os.Stderr
+}
+
+var logging = struct {
+	sync.RWMutex
+	base   slog.Handler
+	level  slog.Level
+	levels map[string]slog.Level
+}{
+	base: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+}
+
+// SetupLogging applies cfg to every logger, including ones created before.
+func SetupLogging(cfg LogConfig) {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // filtered per subsystem
+	var base slog.Handler = slog.NewTextHandler(out, opts)
+	if cfg.JSON {
+		base = slog.NewJSONHandler(out, opts)
+	}
+
+	logging.Lock()
+	defer logging.Unlock()
+	logging.base = base
+	logging.level = cfg.Level
+	logging.levels = cfg.Levels
+	slog.SetDefault(slog.New(&subsystemHandler{}))
+}
+
+// ParseLogLevels parses "info" or "warn,p2p=debug,rpc=error": a default
+// level followed by per-subsystem overrides.
+func ParseLogLevels(s string) (slog.Level, map[string]slog.Level, error) {
+	level := slog.LevelInfo
+	levels := map[string]slog.Level{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, scoped := strings.Cut(part, "=")
+		if !scoped {
+			value = name
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(value)); err != nil {
+			return 0, nil, fmt.Errorf("bad log level %q", value)
+		}
+		if !scoped {
+			level = l
+			continue
+		}
+		known := false
+		for _, sub := range Subsystems {
+			known = known || sub == name
+		}
+		if !known {
+			return 0, nil, fmt.Errorf("unknown log subsystem %q (have %s)", name, strings.Join(Subsystems, ", "))
+		}
+		levels[name] = l
+	}
+	return level, levels, nil
+}
+
+// Logger returns the logger of a subsystem.
+func Logger(subsystem string) *slog.Logger {
+	return slog.New(&subsystemHandler{subsystem: subsystem})
+}
+
+// subsystemHandler forwards to the handler chosen by SetupLogging at the
+// time of each call, so package-level loggers follow reconfiguration.
+type subsystemHandler struct {
+	subsystem string
+	with      []func(slog.Handler) slog.Handler // WithAttrs and WithGroup, in order
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	logging.RLock()
+	defer logging.RUnlock()
+	min, ok := logging.levels[h.subsystem]
+	if !ok {
+		min = logging.level
+	}
+	return level >= min
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	logging.RLock()
+	base := logging.base
+	logging.RUnlock()
+	if h.subsystem != "" {
+		base = base.WithAttrs([]slog.Attr{slog.String("subsystem", h.subsystem)})
+	}
+	for _, with := range h.with {
+		base = with(base)
+	}
+	return base.Handle(ctx, r)
+}
+
+func (h *subsystemHandler) add(with func(slog.Handler) slog.Handler) slog.Handler {
+	c := *h
+	c.with = append(append([]func(slog.Handler) slog.Handler{}, h.with...), with)
+	return &c
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.add(func(b slog.Handler) slog.Handler { return b.WithAttrs(attrs) })
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return h.add(func(b slog.Handler) slog.Handler { return b.WithGroup(name) })
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSubsystemLogging(t *testing.T) {
+	level, levels, err := ParseLogLevels("warn,p2p=debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	SetupLogging(LogConfig{Level: level, Levels: levels, JSON: true, Output: &out})
+	defer SetupLogging(LogConfig{})
+
+	chainLog.Info("hidden", "height", 1)
+	p2pLog.Debug("shown", "peer", "127.0.0.1:3002")
+	chainLog.With("hash", "abc").Warn("also shown", "height", 2)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out.String())
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["subsystem"] != LogChain || rec["hash"] != "abc" || rec["height"] != 2.0 || rec["level"] != slog.LevelWarn.String() {
+		t.Fatalf("unexpected record %v", rec)
+	}
+
+	if _, _, err := ParseLogLevels("info,disk=debug"); err == nil {
+		t.Fatal("unknown subsystem accepted")
+	}
+}
+
+func TestConsensusLogging(t *testing.T) {
+	level, levels, err := ParseLogLevels("error,consensus=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	SetupLogging(LogConfig{Level: level, Levels: levels, JSON: true, Output: &out})
+	defer SetupLogging(LogConfig{})
+
+	x := proposeAs(NewBlockchainWithConfig(poaSpec()), "v1")
+	y := proposeAs(NewBlockchainWithConfig(poaSpec()), "v1")
+	x.SetLogName("x")
+	bx, _ := x.CommitBlock("x", nil)
+	by, _ := y.CommitBlock("y", nil)
+	if err := x.AddEvidence(NewEvidence(bx.Header(), by.Header())); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &rec); err != nil {
+		t.Fatalf("expected one record, got %q", out.String())
+	}
+	if rec["subsystem"] != LogConsensus || rec["node"] != "x" || rec["msg"] != "double sign detected" {
+		t.Fatalf("unexpected record %v", rec)
+	}
+}
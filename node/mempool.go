@@ -0,0 +1,426 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The mempool holds transactions waiting for a block. Each sender has a
+// queue ordered by nonce: transactions that continue the account's nonce
+// without a gap are "pending" and can go into the next block; the rest are
+// "future" and wait for the gap to be filled. Producers pick pending
+// transactions by fee rate (fee per byte).
+
+// ---------------- MEMPOOL CONFIG ----------------
+type MempoolConfig struct {
+	MaxTxs        int           // total transactions held
+	MaxPerAccount int           // transactions held per sender
+	MaxNonceGap   uint64        // how far ahead of the account nonce a tx may be
+	TTL           time.Duration // entries older than this are dropped
+	ReplaceBump   int           // percent fee increase needed to replace a tx
+}
+
+var DefaultMempoolConfig = MempoolConfig{
+	MaxTxs:        5000,
+	MaxPerAccount: 64,
+	MaxNonceGap:   64,
+	TTL:           30 * time.Minute,
+	ReplaceBump:   10,
+}
+
+// MaxBlockBytes is the space for transactions in a block when the genesis
+// spec sets no limit.
+const MaxBlockBytes = 64 * 1024
+
+// ---------------- MEMPOOL STRUCT ----------------
+type mempoolEntry struct {
+	tx    Transaction
+	hash  string
+	size  int
+	added time.Time
+}
+
+// betterRate reports whether a pays a higher fee per byte than b. Ties go
+// to the older entry so ordering is stable.
+func (a *mempoolEntry) betterRate(b *mempoolEntry) bool {
+	l, r := a.tx.Fee*b.size, b.tx.Fee*a.size
+	if l != r {
+		return l > r
+	}
+	if !a.added.Equal(b.added) {
+		return a.added.Before(b.added)
+	}
+	return a.hash < b.hash
+}
+
+type Mempool struct {
+	mu       sync.Mutex
+	cfg      MempoolConfig
+	bc       *Blockchain
+	byHash   map[string]*mempoolEntry
+	bySender map[string]map[uint64]*mempoolEntry // sender -> nonce -> entry
+	journal  *mempoolJournal                     // nil when not persisted
+}
+
+func NewMempool(bc *Blockchain, cfg MempoolConfig) *Mempool {
+	return &Mempool{
+		cfg:      cfg,
+		bc:       bc,
+		byHash:   make(map[string]*mempoolEntry),
+		bySender: make(map[string]map[uint64]*mempoolEntry),
+	}
+}
+
+// accountNonce is the next nonce the chain expects from sender.
+func (mp *Mempool) accountNonce(sender string) uint64 {
+	if acc := mp.bc.State().Get(sender); acc != nil {
+		return acc.Nonce
+	}
+	return 0
+}
+
+// ---------------- ADD ----------------
+// Add queues tx. A transaction with the same sender and nonce as one
+// already queued replaces it if its fee is at least ReplaceBump percent
+// higher. When the pool is full the cheapest transaction is evicted to
+// make room, if tx pays a better fee rate.
+func (mp *Mempool) Add(tx Transaction) error {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	m := mp.bc.metrics
+	err := mp.add(tx, mp.bc.Clock().Now())
+	if err != nil {
+		mp.bc.logs.mempool.Debug("tx rejected", "txid", tx.Hash(), "err", err)
+		m.MempoolRejected.With(rejectReason(err)).Inc()
+	} else {
+		mp.bc.logs.mempool.Debug("tx accepted", "txid", tx.Hash(), "from", tx.From, "nonce", tx.Nonce, "size", len(mp.byHash))
+		m.MempoolAccepted.Inc()
+	}
+	m.MempoolSize.Set(float64(len(mp.byHash)))
+	return err
+}
+
+// add queues tx as if it arrived at added. The caller holds mp.mu.
+func (mp *Mempool) add(tx Transaction, added time.Time) error {
+	now := mp.bc.Clock().Now()
+	mp.expire(now)
+
+	if tx.From == "" {
+		return ErrNotPoolable
+	}
+	if mp.cfg.TTL > 0 && now.Sub(added) > mp.cfg.TTL {
+		return ErrTxExpired
+	}
+	e := &mempoolEntry{tx: tx, hash: tx.Hash(), size: tx.Size(), added: added}
+	if _, ok := mp.byHash[e.hash]; ok {
+		return ErrAlreadyKnown
+	}
+
+	// Future nonces are allowed here; they wait in the sender's queue.
+	ctx := mp.bc.RuleContext()
+	ctx.MaxNonceGap = mp.cfg.MaxNonceGap
+	if err := ValidateTx(tx, ctx, DefaultRules); err != nil {
+		return err
+	}
+
+	queue := mp.bySender[tx.From]
+	if old, ok := queue[tx.Nonce]; ok {
+		if tx.Fee*100 < old.tx.Fee*(100+mp.cfg.ReplaceBump) || tx.Fee <= old.tx.Fee {
+			return ErrReplaceUnderpriced
+		}
+		mp.remove(old)
+		mp.insert(e)
+		mp.bc.logs.mempool.Debug("tx replaced", "txid", old.hash, "by", e.hash)
+		mp.bc.metrics.MempoolEvicted.With("replaced").Inc()
+		return nil
+	}
+	if len(queue) >= mp.cfg.MaxPerAccount {
+		return ErrAccountLimit
+	}
+	if len(mp.byHash) >= mp.cfg.MaxTxs {
+		victim := mp.evictionCandidate()
+		if victim == nil || !e.betterRate(victim) {
+			return ErrMempoolFull
+		}
+		mp.remove(victim)
+		mp.bc.logs.mempool.Debug("tx evicted", "txid", victim.hash, "for", e.hash)
+		mp.bc.metrics.MempoolEvicted.With("evicted").Inc()
+	}
+	mp.insert(e)
+	return nil
+}
+
+func (mp *Mempool) insert(e *mempoolEntry) {
+	if mp.bySender[e.tx.From] == nil {
+		mp.bySender[e.tx.From] = make(map[uint64]*mempoolEntry)
+	}
+	mp.bySender[e.tx.From][e.tx.Nonce] = e
+	mp.byHash[e.hash] = e
+	if mp.journal != nil {
+		mp.journal.append(e)
+	}
+}
+
+func (mp *Mempool) remove(e *mempoolEntry) {
+	delete(mp.byHash, e.hash)
+	delete(mp.bySender[e.tx.From], e.tx.Nonce)
+	if len(mp.bySender[e.tx.From]) == 0 {
+		delete(mp.bySender, e.tx.From)
+	}
+}
+
+// evictionCandidate returns the cheapest transaction that is last in its
+// sender's queue, so evicting it never opens a nonce gap.
+func (mp *Mempool) evictionCandidate() *mempoolEntry {
+	var victim *mempoolEntry
+	for _, queue := range mp.bySender {
+		var last *mempoolEntry
+		for _, e := range queue {
+			if last == nil || e.tx.Nonce > last.tx.Nonce {
+				last = e
+			}
+		}
+		if victim == nil || victim.betterRate(last) {
+			victim = last
+		}
+	}
+	return victim
+}
+
+// expire drops entries older than TTL.
+func (mp *Mempool) expire(now time.Time) {
+	if mp.cfg.TTL <= 0 {
+		return
+	}
+	for _, e := range mp.byHash {
+		if now.Sub(e.added) > mp.cfg.TTL {
+			mp.remove(e)
+			mp.bc.logs.mempool.Debug("tx expired", "txid", e.hash)
+			mp.bc.metrics.MempoolEvicted.With("expired").Inc()
+		}
+	}
+}
+
+// ---------------- QUERIES ----------------
+func (mp *Mempool) Size() int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	return len(mp.byHash)
+}
+
+// NextNonce is the nonce a new transaction from sender should use.
+func (mp *Mempool) NextNonce(sender string) uint64 {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	nonce := mp.accountNonce(sender)
+	for mp.bySender[sender][nonce] != nil {
+		nonce++
+	}
+	return nonce
+}
+
+// readyQueue returns sender's transactions that continue the account nonce
+// without a gap, in nonce order.
+func (mp *Mempool) readyQueue(sender string) []*mempoolEntry {
+	var out []*mempoolEntry
+	for nonce := mp.accountNonce(sender); ; nonce++ {
+		e := mp.bySender[sender][nonce]
+		if e == nil {
+			return out
+		}
+		out = append(out, e)
+	}
+}
+
+// Pending returns transactions that can go into the next block, grouped
+// by sender in nonce order.
+func (mp *Mempool) Pending() []Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	var out []Transaction
+	for _, sender := range mp.senders() {
+		for _, e := range mp.readyQueue(sender) {
+			out = append(out, e.tx)
+		}
+	}
+	return out
+}
+
+// Future returns transactions waiting for an earlier nonce.
+func (mp *Mempool) Future() []Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	var out []Transaction
+	for _, sender := range mp.senders() {
+		ready := len(mp.readyQueue(sender))
+		nonces := make([]uint64, 0, len(mp.bySender[sender]))
+		for n := range mp.bySender[sender] {
+			nonces = append(nonces, n)
+		}
+		sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+		for _, n := range nonces[ready:] {
+			out = append(out, mp.bySender[sender][n].tx)
+		}
+	}
+	return out
+}
+
+func (mp *Mempool) senders() []string {
+	out := make([]string, 0, len(mp.bySender))
+	for s := range mp.bySender {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ---------------- BLOCK TEMPLATE ----------------
+// BlockTemplate is the set of transactions a producer should put in its
+// next block, and the ones it left out.
+type BlockTemplate struct {
+	Txs     []Transaction `json:"txs"`
+	Bytes   int           `json:"bytes"`
+	Skipped []SkippedTx   `json:"skipped,omitempty"`
+}
+
+// Reasons a template leaves a transaction out.
+const (
+	SkipBlockBytes = "block_bytes" // it would take the block over MaxBlockBytes
+	SkipBlockTxs   = "block_txs"   // the block already has MaxBlockTxs
+	SkipTxBytes    = "tx_bytes"    // it is larger than MaxTxBytes
+	SkipInvalid    = "invalid"     // it does not apply to the state before it
+	SkipBehind     = "behind"      // an earlier nonce of its sender was left out
+	SkipFuture     = "future"      // it waits for a nonce not in the pool
+)
+
+// SkippedTx is a transaction left out of a template.
+type SkippedTx struct {
+	Hash   string `json:"hash"`
+	From   string `json:"from"`
+	Nonce  uint64 `json:"nonce"`
+	Fee    int    `json:"fee"`
+	Size   int    `json:"size"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func skipped(e *mempoolEntry, reason, detail string) SkippedTx {
+	return SkippedTx{Hash: e.hash, From: e.tx.From, Nonce: e.tx.Nonce, Fee: e.tx.Fee, Size: e.size, Reason: reason, Detail: detail}
+}
+
+// Template picks pending transactions by fee rate until the block limits
+// are reached; a limit of 0 is no limit. Each sender's transactions stay
+// in nonce order, and each one is checked against the current state so
+// the block is sure to apply. Every transaction left out is listed in
+// Skipped with the reason.
+func (mp *Mempool) Template(limits Limits) *BlockTemplate {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.expire(mp.bc.Clock().Now())
+
+	state := mp.bc.State().Copy()
+	tmpl := &BlockTemplate{}
+	queues := make(map[string][]*mempoolEntry)
+	var future []SkippedTx
+	for _, sender := range mp.senders() {
+		q := mp.readyQueue(sender)
+		if len(q) > 0 {
+			queues[sender] = q
+		}
+		nonces := make([]uint64, 0, len(mp.bySender[sender]))
+		for n := range mp.bySender[sender] {
+			nonces = append(nonces, n)
+		}
+		sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+		for _, n := range nonces[len(q):] {
+			future = append(future, skipped(mp.bySender[sender][n], SkipFuture, ""))
+		}
+	}
+
+	for len(queues) > 0 {
+		var best *mempoolEntry
+		for _, q := range queues {
+			if best == nil || q[0].betterRate(best) {
+				best = q[0]
+			}
+		}
+		sender := best.tx.From
+
+		if limits.MaxBlockTxs > 0 && len(tmpl.Txs) == limits.MaxBlockTxs {
+			for _, s := range mp.senders() {
+				for _, e := range queues[s] {
+					tmpl.Skipped = append(tmpl.Skipped, skipped(e, SkipBlockTxs, ""))
+				}
+			}
+			break
+		}
+		// A sender whose next transaction cannot go in is done for this
+		// block: its later nonces would leave a gap.
+		var reason, detail string
+		switch {
+		case limits.MaxTxBytes > 0 && best.size > limits.MaxTxBytes:
+			reason, detail = SkipTxBytes, fmt.Sprintf("%d bytes, limit %d", best.size, limits.MaxTxBytes)
+		case limits.MaxBlockBytes > 0 && tmpl.Bytes+best.size > limits.MaxBlockBytes:
+			reason, detail = SkipBlockBytes, fmt.Sprintf("%d bytes, %d left", best.size, limits.MaxBlockBytes-tmpl.Bytes)
+		default:
+			if err := state.ApplyTx(best.tx); err != nil {
+				reason, detail = SkipInvalid, err.Error()
+			}
+		}
+		if reason != "" {
+			tmpl.Skipped = append(tmpl.Skipped, skipped(best, reason, detail))
+			for _, e := range queues[sender][1:] {
+				tmpl.Skipped = append(tmpl.Skipped, skipped(e, SkipBehind, fmt.Sprintf("nonce %d left out", best.tx.Nonce)))
+			}
+			delete(queues, sender)
+			continue
+		}
+		tmpl.Txs = append(tmpl.Txs, best.tx)
+		tmpl.Bytes += best.size
+		if queues[sender] = queues[sender][1:]; len(queues[sender]) == 0 {
+			delete(queues, sender)
+		}
+	}
+	tmpl.Skipped = append(tmpl.Skipped, future...)
+	return tmpl
+}
+
+// ---------------- BLOCK UPDATES ----------------
+// Update drops transactions whose nonces the chain has already used, for
+// example after a block including them was committed.
+func (mp *Mempool) Update() {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	for sender, queue := range mp.bySender {
+		base := mp.accountNonce(sender)
+		for nonce, e := range queue {
+			if nonce < base {
+				mp.remove(e)
+			}
+		}
+	}
+	mp.bc.metrics.MempoolSize.Set(float64(len(mp.byHash)))
+	if mp.journal != nil {
+		if err := mp.journal.rewrite(mp.byHash); err != nil {
+			mp.bc.logs.mempool.Error("rewriting journal failed", "path", mp.journal.path, "err", err)
+		}
+	}
+}
+
+// ProduceBlock commits a block built from the mempool's template and
+// removes the included transactions from the pool.
+func (bc *Blockchain) ProduceBlock(mp *Mempool, data string) (Block, error) {
+	tmpl := mp.Template(bc.Config().BlockLimits())
+	block, err := bc.CommitBlock(data, tmpl.Txs)
+	if err != nil {
+		return block, err
+	}
+	if len(tmpl.Skipped) > 0 {
+		bc.logs.mempool.Debug("transactions left out of block", "height", block.Index, "count", len(tmpl.Skipped))
+	}
+	mp.Update()
+	bc.metrics.Proposed.Inc()
+	return block, nil
+}
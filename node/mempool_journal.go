@@ -0,0 +1,153 @@
+package node
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+)
+
+// The mempool journal is a file with one JSON record per line. Accepted
+// transactions are appended as they arrive, and the whole file is
+// rewritten from the pool after every block so it does not grow forever.
+
+const MempoolJournalFile = "mempool.journal"
+
+type journalRecord struct {
+	Tx    Transaction `json:"Tx"`
+	Added time.Time   `json:"Added"`
+}
+
+type mempoolJournal struct {
+	path string
+	file *os.File
+	log  *slog.Logger
+}
+
+func (j *mempoolJournal) append(e *mempoolEntry) {
+	b, _ := json.Marshal(journalRecord{Tx: e.tx, Added: e.added})
+	if _, err := j.file.Write(append(b, '\n')); err != nil {
+		j.log.Error("writing journal failed", "path", j.path, "err", err)
+	}
+}
+
+// rewrite replaces the journal with the given entries, oldest first.
+func (j *mempoolJournal) rewrite(entries map[string]*mempoolEntry) error {
+	list := make([]*mempoolEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].added.Before(list[b].added) })
+
+	tmp := j.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, e := range list {
+		b, _ := json.Marshal(journalRecord{Tx: e.tx, Added: e.added})
+		w.Write(append(b, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+func readJournal(path string, log *slog.Logger) ([]journalRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []journalRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// A crash can leave a half-written last line.
+			log.Warn("skipping unreadable journal line", "path", path, "err", err)
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// ---------------- LOAD JOURNAL ----------------
+// LoadJournal re-adds the transactions journaled at path, checking each
+// against the current chain state. Transactions that no longer apply are
+// dropped with a reason. The journal is then rewritten and kept up to date
+// from here on. It returns the transactions that were restored.
+func (mp *Mempool) LoadJournal(path string) ([]Transaction, error) {
+	records, err := readJournal(path, mp.bc.logs.mempool)
+	if err != nil {
+		return nil, err
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for _, r := range records {
+		if err := mp.add(r.Tx, r.Added); err != nil {
+			if errors.Is(err, ErrAlreadyKnown) {
+				continue
+			}
+			reason := err.Error()
+			if errors.Is(err, ErrNonceTooLow) {
+				reason = "already included in the chain"
+			}
+			mp.bc.logs.mempool.Info("dropped journaled tx", "txid", r.Tx.Hash(), "reason", reason)
+		}
+	}
+
+	mp.bc.metrics.MempoolSize.Set(float64(len(mp.byHash)))
+	var restored []Transaction
+	for _, e := range mp.byHash {
+		restored = append(restored, e.tx)
+	}
+	sort.Slice(restored, func(a, b int) bool {
+		if restored[a].From != restored[b].From {
+			return restored[a].From < restored[b].From
+		}
+		return restored[a].Nonce < restored[b].Nonce
+	})
+
+	mp.journal = &mempoolJournal{path: path, log: mp.bc.logs.mempool}
+	if err := mp.journal.rewrite(mp.byHash); err != nil {
+		mp.journal = nil
+		return restored, err
+	}
+	return restored, nil
+}
+
+// CloseJournal stops journaling and closes the journal file.
+func (mp *Mempool) CloseJournal() error {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	if mp.journal == nil {
+		return nil
+	}
+	err := mp.journal.file.Close()
+	mp.journal = nil
+	return err
+}
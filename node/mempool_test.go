@@ -0,0 +1,204 @@
+package node
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newFundedMempool(t *testing.T, cfg MempoolConfig, accounts ...string) (*Blockchain, *Mempool) {
+	t.Helper()
+	bc := NewBlockchainWithConfig(fundedSpec(1000, accounts...))
+	return bc, NewMempool(bc, cfg)
+}
+
+func TestMempoolOrdersByFeeRate(t *testing.T) {
+	_, mp := newFundedMempool(t, DefaultMempoolConfig, "alice", "bob", "carol")
+
+	mp.Add(transfer("alice", "x", 1, 1, 0))
+	mp.Add(transfer("bob", "x", 1, 5, 0))
+	mp.Add(transfer("carol", "x", 1, 3, 0))
+
+	tmpl := mp.Template(DefaultConfig().Limits)
+	if len(tmpl.Txs) != 3 {
+		t.Fatalf("expected 3 txs, got %d", len(tmpl.Txs))
+	}
+	for i, want := range []string{"bob", "carol", "alice"} {
+		if tmpl.Txs[i].From != addr(want) {
+			t.Fatalf("position %d: expected %s, got %s", i, want, tmpl.Txs[i].From)
+		}
+	}
+}
+
+func TestMempoolNonceQueues(t *testing.T) {
+	bc, mp := newFundedMempool(t, DefaultMempoolConfig, "alice")
+
+	// Nonce 2 arrives first and must wait for 0 and 1.
+	mp.Add(transfer("alice", "x", 1, 9, 2))
+	if len(mp.Pending()) != 0 || len(mp.Future()) != 1 {
+		t.Fatal("gapped transaction should be future")
+	}
+	mp.Add(transfer("alice", "x", 1, 1, 0))
+	mp.Add(transfer("alice", "x", 1, 1, 1))
+	if len(mp.Pending()) != 3 || len(mp.Future()) != 0 {
+		t.Fatal("filled gap should make all transactions pending")
+	}
+
+	// Despite its higher fee, nonce 2 stays behind 0 and 1.
+	tmpl := mp.Template(DefaultConfig().Limits)
+	for i, tx := range tmpl.Txs {
+		if tx.Nonce != uint64(i) {
+			t.Fatalf("template out of nonce order: %+v", tmpl.Txs)
+		}
+	}
+
+	if _, err := bc.ProduceBlock(mp, "block"); err != nil {
+		t.Fatal(err)
+	}
+	if mp.Size() != 0 {
+		t.Fatal("included transactions left in mempool")
+	}
+	if err := mp.Add(transfer("alice", "x", 1, 0, 1)); !errors.Is(err, ErrNonceTooLow) {
+		t.Fatalf("expected stale nonce rejection, got %v", err)
+	}
+}
+
+func TestMempoolReplaceByFee(t *testing.T) {
+	_, mp := newFundedMempool(t, DefaultMempoolConfig, "alice")
+
+	mp.Add(transfer("alice", "x", 1, 100, 0))
+	if err := mp.Add(transfer("alice", "y", 1, 105, 0)); !errors.Is(err, ErrReplaceUnderpriced) {
+		t.Fatalf("expected underpriced replacement, got %v", err)
+	}
+	if err := mp.Add(transfer("alice", "y", 1, 110, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if p := mp.Pending(); len(p) != 1 || p[0].To != addr("y") {
+		t.Fatalf("replacement not applied: %+v", p)
+	}
+}
+
+func TestMempoolLimitsAndEviction(t *testing.T) {
+	cfg := DefaultMempoolConfig
+	cfg.MaxTxs = 2
+	cfg.MaxPerAccount = 1
+	_, mp := newFundedMempool(t, cfg, "alice", "bob", "carol", "dave")
+
+	mp.Add(transfer("alice", "x", 1, 1, 0))
+	if err := mp.Add(transfer("alice", "x", 1, 1, 1)); !errors.Is(err, ErrAccountLimit) {
+		t.Fatalf("expected account limit, got %v", err)
+	}
+	mp.Add(transfer("bob", "x", 1, 5, 0))
+
+	// Full: a cheaper tx is refused, a better one evicts alice's.
+	if err := mp.Add(transfer("carol", "x", 1, 0, 0)); !errors.Is(err, ErrMempoolFull) {
+		t.Fatalf("expected full mempool, got %v", err)
+	}
+	if err := mp.Add(transfer("dave", "x", 1, 9, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range mp.Pending() {
+		if tx.From == addr("alice") {
+			t.Fatal("lowest fee transaction was not evicted")
+		}
+	}
+}
+
+func TestMempoolExpiry(t *testing.T) {
+	_, mp := newFundedMempool(t, DefaultMempoolConfig, "alice")
+	mp.Add(transfer("alice", "x", 1, 0, 0))
+
+	mp.mu.Lock()
+	mp.expire(time.Now().Add(DefaultMempoolConfig.TTL + time.Second))
+	mp.mu.Unlock()
+	if mp.Size() != 0 {
+		t.Fatal("stale transaction was not expired")
+	}
+}
+
+func TestTemplateRespectsBlockSize(t *testing.T) {
+	_, mp := newFundedMempool(t, DefaultMempoolConfig, "alice", "bob")
+	a := transfer("alice", "x", 1, 10, 0)
+	b := transfer("bob", "x", 1, 1, 0)
+	mp.Add(a)
+	mp.Add(b)
+
+	tmpl := mp.Template(Limits{MaxBlockBytes: a.Size()})
+	if len(tmpl.Txs) != 1 || tmpl.Txs[0].From != addr("alice") || tmpl.Bytes > a.Size() {
+		t.Fatalf("template ignored size limit: %+v", tmpl)
+	}
+}
+
+func TestMempoolJournal(t *testing.T) {
+	bc, mp := newFundedMempool(t, DefaultMempoolConfig, "alice", "bob")
+	path := filepath.Join(t.TempDir(), MempoolJournalFile)
+	if _, err := mp.LoadJournal(path); err != nil {
+		t.Fatal(err)
+	}
+
+	mp.Add(transfer("alice", "x", 1, 1, 0))
+	mp.Add(transfer("alice", "x", 1, 1, 1))
+	mp.Add(transfer("bob", "x", 1, 1, 0))
+
+	// Simulate a restart where alice's first transaction was mined by
+	// another node meanwhile: it must be dropped, the rest restored.
+	bc.CommitBlock("elsewhere", []Transaction{transfer("alice", "x", 1, 1, 0)})
+	restarted := NewMempool(bc, DefaultMempoolConfig)
+	restored, err := restarted.LoadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 2 || restarted.Size() != 2 {
+		t.Fatalf("expected 2 restored transactions, got %d", len(restored))
+	}
+	for _, tx := range restored {
+		if tx.From == addr("alice") && tx.Nonce == 0 {
+			t.Fatal("included transaction was restored")
+		}
+	}
+
+	// After the next block the journal only holds what is still pending.
+	if _, err := bc.ProduceBlock(restarted, "block"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := readJournal(path, mempoolLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("journal not rewritten after block: %d records", len(records))
+	}
+}
+
+func TestTemplateReportsSkipped(t *testing.T) {
+	_, mp := newFundedMempool(t, DefaultMempoolConfig, "alice", "bob", "carol")
+	mp.Add(transfer("alice", "x", 1, 9, 0))
+	mp.Add(transfer("alice", "x", 1, 9, 1))
+	mp.Add(transfer("bob", "x", 1, 5, 0))
+	mp.Add(transfer("bob", "x", 1, 5, 1))
+	mp.Add(transfer("carol", "x", 1, 7, 3))
+
+	tmpl := mp.Template(Limits{MaxBlockTxs: 3})
+	if len(tmpl.Txs) != 3 || tmpl.Txs[2].From != addr("bob") {
+		t.Fatalf("expected alice's two and bob's first, got %+v", tmpl.Txs)
+	}
+	reasons := map[string]string{}
+	for _, s := range tmpl.Skipped {
+		reasons[s.From] = s.Reason
+	}
+	if len(tmpl.Skipped) != 2 || reasons[addr("bob")] != SkipBlockTxs || reasons[addr("carol")] != SkipFuture {
+		t.Fatalf("unexpected skipped list: %+v", tmpl.Skipped)
+	}
+
+	first := transfer("alice", "x", 1, 9, 0)
+	tmpl = mp.Template(Limits{MaxBlockBytes: first.Size()})
+	for _, s := range tmpl.Skipped {
+		if s.From == addr("alice") && s.Nonce == 1 && s.Reason != SkipBlockBytes {
+			t.Fatalf("alice's second tx skipped for %s", s.Reason)
+		}
+		if s.From == addr("bob") && s.Nonce == 1 && s.Reason != SkipBehind {
+			t.Fatalf("bob's second tx skipped for %s", s.Reason)
+		}
+	}
+}
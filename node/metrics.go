@@ -0,0 +1,104 @@
+package node
+
+import (
+	"errors"
+
+	"proco-node/metrics"
+)
+
+// ---------------- METRICS ----------------
+// Metrics are the counters, gauges and histograms of one node. Every chain
+// owns a registry, so several nodes in one process do not share numbers;
+// the mempool and the network record into the registry of their chain.
+type Metrics struct {
+	Registry *metrics.Registry
+
+	// chain
+	Height         *metrics.Gauge
+	Blocks         *metrics.Counter
+	BlockTxs       *metrics.Histogram
+	BlockCommit    *metrics.Histogram
+	InvalidBlocks  *metrics.Counter
+	Reorgs         *metrics.Counter
+	SnapshotsTaken *metrics.Counter
+
+	// mempool
+	MempoolSize     *metrics.Gauge
+	MempoolAccepted *metrics.Counter
+	MempoolRejected *metrics.CounterVec // by reason
+	MempoolEvicted  *metrics.CounterVec // by reason: replaced, evicted, expired
+
+	// p2p
+	Peers          *metrics.Gauge
+	SyncLag        *metrics.Gauge
+	MessagesIn     *metrics.CounterVec // by type
+	MessagesOut    *metrics.CounterVec // by type
+	SendFailures   *metrics.Counter
+	FaultsInjected *metrics.CounterVec // by fault actions
+
+	// consensus
+	Proposed      *metrics.Counter
+	BlockInterval *metrics.Histogram
+	DoubleSigns   *metrics.Counter
+
+	// rpc
+	RPCRequests *metrics.CounterVec // by method
+	RPCErrors   *metrics.CounterVec // by method
+	RPCDuration *metrics.HistogramVec
+}
+
+// txCountBuckets are bucket bounds for transactions per block.
+var txCountBuckets = []float64{0, 1, 5, 10, 50, 100, 500, 1000}
+
+// intervalBuckets are bucket bounds in seconds for the time between blocks.
+var intervalBuckets = []float64{1, 2, 5, 10, 30, 60, 120, 300, 600}
+
+func NewMetrics() *Metrics {
+	r := metrics.NewRegistry()
+	return &Metrics{
+		Registry: r,
+
+		Height:         r.NewGauge("proco_chain_height", "Index of the last block."),
+		Blocks:         r.NewCounter("proco_chain_blocks_total", "Blocks committed since start."),
+		BlockTxs:       r.NewHistogram("proco_chain_block_txs", "Transactions per committed block.", txCountBuckets),
+		BlockCommit:    r.NewHistogram("proco_chain_block_commit_seconds", "Time to apply and store a block.", nil),
+		InvalidBlocks:  r.NewCounter("proco_chain_invalid_blocks_total", "Blocks that failed validation."),
+		Reorgs:         r.NewCounter("proco_chain_reorgs_total", "Switches to a longer fork."),
+		SnapshotsTaken: r.NewCounter("proco_chain_snapshots_total", "State snapshots written."),
+
+		MempoolSize:     r.NewGauge("proco_mempool_size", "Transactions in the mempool."),
+		MempoolAccepted: r.NewCounter("proco_mempool_accepted_total", "Transactions accepted into the mempool."),
+		MempoolRejected: r.NewCounterVec("proco_mempool_rejected_total", "Transactions refused by the mempool.", "reason"),
+		MempoolEvicted:  r.NewCounterVec("proco_mempool_removed_total", "Transactions dropped from the mempool before inclusion.", "reason"),
+
+		Peers:          r.NewGauge("proco_p2p_peers", "Configured peers."),
+		SyncLag:        r.NewGauge("proco_p2p_sync_lag_blocks", "Blocks the best known peer is ahead of us."),
+		MessagesIn:     r.NewCounterVec("proco_p2p_messages_received_total", "Messages received, by type.", "type"),
+		MessagesOut:    r.NewCounterVec("proco_p2p_messages_sent_total", "Messages sent, by type.", "type"),
+		SendFailures:   r.NewCounter("proco_p2p_send_failures_total", "Messages that could not be delivered."),
+		FaultsInjected: r.NewCounterVec("proco_p2p_faults_injected_total", "Messages changed by fault rules, by actions.", "fault"),
+
+		Proposed:      r.NewCounter("proco_consensus_blocks_proposed_total", "Blocks produced by this node."),
+		BlockInterval: r.NewHistogram("proco_consensus_block_interval_seconds", "Time between consecutive blocks.", intervalBuckets),
+		DoubleSigns:   r.NewCounter("proco_consensus_double_signs_total", "Double signs by validators detected here."),
+
+		RPCRequests: r.NewCounterVec("proco_rpc_requests_total", "RPC calls, by method.", "method"),
+		RPCErrors:   r.NewCounterVec("proco_rpc_errors_total", "RPC calls that returned an error, by method.", "method"),
+		RPCDuration: r.NewHistogramVec("proco_rpc_request_seconds", "RPC call latency, by method.", nil, "method"),
+	}
+}
+
+// Metrics returns the registry of the node this chain belongs to.
+func (bc *Blockchain) Metrics() *Metrics {
+	return bc.metrics
+}
+
+// rejectReason is a short label for why a transaction was refused: the
+// failed rule, or the mempool error.
+func rejectReason(err error) string {
+	var txErr *TxError
+	if errors.As(err, &txErr) {
+		return txErr.Rule
+	}
+	return err.Error()
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"proco-node/metrics"
+)
+
+func TestMetricsTextFormat(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounterVec("requests_total", "Requests.", "method")
+	c.With("a").Inc()
+	c.With(`b"q`).Add(2)
+	r.NewGauge("size", "Size.").Set(3)
+	h := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 2})
+	h.Observe(0.5)
+	h.Observe(1.5)
+	h.Observe(5)
+
+	var out bytes.Buffer
+	r.WriteText(&out)
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="1"} 1
+latency_seconds_bucket{le="2"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 7
+latency_seconds_count 3
+# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{method="a"} 1
+requests_total{method="b\"q"} 2
+# HELP size Size.
+# TYPE size gauge
+size 3
+`
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestNodeMetrics(t *testing.T) {
+	bc, mp := newFundedMempool(t, DefaultMempoolConfig, "alice")
+	mp.Add(transfer("alice", "x", 1, 1, 0))
+	mp.Add(transfer("alice", "x", 1, 1, 0))    // already known
+	mp.Add(transfer("alice", "x", 1000, 1, 1)) // balance
+	if _, err := bc.ProduceBlock(mp, "block"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := bc.Metrics()
+	if m.Height.Value() != 1 || m.Blocks.Value() != 1 || m.Proposed.Value() != 1 {
+		t.Fatalf("chain metrics: height %v blocks %v proposed %v", m.Height.Value(), m.Blocks.Value(), m.Proposed.Value())
+	}
+	if m.MempoolAccepted.Value() != 1 || m.MempoolSize.Value() != 0 {
+		t.Fatalf("mempool metrics: accepted %v size %v", m.MempoolAccepted.Value(), m.MempoolSize.Value())
+	}
+
+	var out bytes.Buffer
+	m.Registry.WriteText(&out)
+	for _, line := range []string{
+		"proco_chain_height 1",
+		`proco_mempool_rejected_total{reason="balance"} 1`,
+		`proco_mempool_rejected_total{reason="` + ErrAlreadyKnown.Error() + `"} 1`,
+		`proco_chain_block_txs_bucket{le="1"} 1`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out.String())
+		}
+	}
+}
@@ -9,7 +9,9 @@ func TestAddBlock(t *testing.T) {
 		t.Fatalf("expected genesis block, got %d blocks", len(bc.Blocks))
 	}
 
-	bc.AddBlock("test transaction")
+	if err := bc.AddBlock("test transaction"); err != nil {
+		t.Fatal(err)
+	}
 
 	if len(bc.Blocks) != 2 {
 		t.Fatalf("expected 2 blocks after adding, got %d", len(bc.Blocks))
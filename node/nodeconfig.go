@@ -0,0 +1,83 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Files kept in a node's data directory.
+const (
+	NodeConfigFile = "node.json"
+	GenesisFile    = "genesis.json"
+	ChainFile      = "blocks.json"
+	WalletsFile    = "wallets.json"
+)
+
+// ---------------- NODE CONFIG ----------------
+// NodeConfig is the local setup of one node, as opposed to the genesis
+// spec shared by the whole network. Relative paths in it are relative to
+// the data directory.
+type NodeConfig struct {
+	Genesis          string   `json:"genesis"`
+	P2PAddr          string   `json:"p2p_addr"`
+	RPCAddr          string   `json:"rpc_addr"`
+	Peers            []string `json:"peers"`
+	Proposer         string   `json:"proposer,omitempty"` // address credited with block fees
+	SnapshotInterval int      `json:"snapshot_interval,omitempty"`
+	PruneDepth       int      `json:"prune_depth,omitempty"`
+	Indexes          []string `json:"indexes,omitempty"` // missing means all; [] means none
+
+	DataDir string `json:"-"`
+}
+
+// LoadNodeConfig reads node.json from dir. A directory without one gets a
+// default setup, so a bare directory is enough to run a dev node.
+func LoadNodeConfig(dir string) (*NodeConfig, error) {
+	cfg := &NodeConfig{P2PAddr: DefaultP2PAddr}
+	data, err := os.ReadFile(filepath.Join(dir, NodeConfigFile))
+	if err == nil {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%s: %v", NodeConfigFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	cfg.DataDir = dir
+	return cfg, nil
+}
+
+// Save writes the config to node.json in its data directory.
+func (c *NodeConfig) Save() error {
+	return writeJSONFile(filepath.Join(c.DataDir, NodeConfigFile), c, 0o644)
+}
+
+// Path resolves a file name against the data directory.
+func (c *NodeConfig) Path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.DataDir, name)
+}
+
+// LoadGenesis loads the genesis spec named by the config. Without one the
+// node runs DefaultConfig.
+func (c *NodeConfig) LoadGenesis() (*Config, error) {
+	if c.Genesis == "" {
+		return DefaultConfig(), nil
+	}
+	return LoadConfig(c.Path(c.Genesis))
+}
+
+// writeJSONFile writes v as indented JSON.
+func writeJSONFile(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), perm)
+}
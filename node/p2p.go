@@ -0,0 +1,725 @@
+package node
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nodes talk over TCP with one JSON message per line. Every message is
+// sent on its own short-lived connection; a request gets its reply on the
+// same connection before it is closed. Gossip messages have no reply.
+//
+// A node greets its peers with HELLO on start and every HelloInterval
+// after; the reply is a PEER_LIST, so nodes learn about each other. Every
+// message carries the sender's height and head, and a node that sees a
+// peer ahead of it fetches the missing blocks with GET_BLOCKS. A node
+// that finds a validator's signature on two blocks of one height gossips
+// the pair as EVIDENCE.
+
+const (
+	DefaultP2PAddr = "127.0.0.1:3001"
+	DialTimeout    = 5 * time.Second
+	MessageTimeout = 30 * time.Second
+	HelloInterval  = 10 * time.Second
+)
+
+// ---------------- MESSAGE TYPES ----------------
+const (
+	MsgTypeHello       = "HELLO"
+	MsgTypePeerList    = "PEER_LIST"
+	MsgTypeTx          = "TX"
+	MsgTypeBlock       = "BLOCK"
+	MsgTypeGetSnapshot = "GET_SNAPSHOT"
+	MsgTypeSnapshot    = "SNAPSHOT"
+	MsgTypeGetBlocks   = "GET_BLOCKS"
+	MsgTypeBlocks      = "BLOCKS"
+	MsgTypeError       = "ERROR"
+	MsgTypeEvidence    = "EVIDENCE"
+)
+
+// NetMessage is the envelope for every network message.
+type NetMessage struct {
+	Type   string          `json:"type"`
+	From   string          `json:"from"`             // listen address of the sender
+	Height int             `json:"height,omitempty"` // chain height of the sender
+	Head   string          `json:"head,omitempty"`   // hash of the sender's last block
+	Body   json.RawMessage `json:"body"`
+}
+
+// Hello is the body of a HELLO message.
+type Hello struct {
+	Genesis string `json:"genesis"`
+	RPC     string `json:"rpc,omitempty"` // RPC address of the sender, if it serves one
+}
+
+// PeerStatus is what a node last heard from a peer.
+type PeerStatus struct {
+	Addr     string    `json:"addr"`
+	RPC      string    `json:"rpc,omitempty"`
+	Height   int       `json:"height"`
+	Head     string    `json:"head,omitempty"`
+	LastSeen time.Time `json:"last_seen,omitempty"`
+}
+
+// ---------------- NETWORK ----------------
+type Network struct {
+	listenAddr string
+	rpcAddr    string
+	bc         *Blockchain
+	mempool    *Mempool
+	ln         net.Listener
+	quit       chan struct{}
+
+	mu      sync.Mutex
+	peers   []string
+	status  map[string]*PeerStatus // by peer address
+	watches map[chan NetEvent]struct{}
+	cut     map[string]bool // peers we are partitioned from
+	faults  *faults
+	syncing sync.Mutex // held while fetching blocks from a peer
+}
+
+func NewNetwork(listenAddr string, bc *Blockchain, mp *Mempool, peers []string) *Network {
+	bc.metrics.Peers.Set(float64(len(peers)))
+	return &Network{
+		listenAddr: listenAddr,
+		bc:         bc,
+		mempool:    mp,
+		peers:      append([]string(nil), peers...),
+		quit:       make(chan struct{}),
+		status:     make(map[string]*PeerStatus),
+		watches:    make(map[chan NetEvent]struct{}),
+		cut:        make(map[string]bool),
+		faults:     &faults{nextID: 1},
+	}
+}
+
+// SetRPCAddr sets the RPC address announced to peers in HELLO.
+func (n *Network) SetRPCAddr(addr string) {
+	n.rpcAddr = addr
+}
+
+// Peers returns the addresses this node knows about.
+func (n *Network) Peers() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.peers...)
+}
+
+// addPeers remembers new peer addresses.
+func (n *Network) addPeers(addrs []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, addr := range addrs {
+		known := addr == "" || addr == n.listenAddr
+		for _, p := range n.peers {
+			known = known || p == addr
+		}
+		if !known {
+			n.peers = append(n.peers, addr)
+			n.bc.logs.p2p.Info("peer added", "peer", addr)
+		}
+	}
+	n.bc.metrics.Peers.Set(float64(len(n.peers)))
+}
+
+func (n *Network) Start() error {
+	ln, err := net.Listen("tcp", n.listenAddr)
+	if err != nil {
+		return err
+	}
+	n.ln = ln
+	go n.acceptLoop()
+	go n.helloLoop()
+	n.bc.logs.p2p.Info("listening", "addr", n.listenAddr, "peers", len(n.Peers()))
+	return nil
+}
+
+func (n *Network) Stop() {
+	close(n.quit)
+	if n.ln != nil {
+		n.ln.Close()
+	}
+}
+
+func (n *Network) acceptLoop() {
+	for {
+		conn, err := n.ln.Accept()
+		if err != nil {
+			select {
+			case <-n.quit:
+				return
+			default:
+				n.bc.logs.p2p.Warn("accept failed", "err", err)
+				continue
+			}
+		}
+		go n.handleConn(conn)
+	}
+}
+
+func (n *Network) handleConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(MessageTimeout))
+
+	remote := conn.RemoteAddr().String()
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		n.bc.logs.p2p.Debug("reading message failed", "peer", remote, "err", err)
+		return
+	}
+	var msg NetMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		n.bc.logs.p2p.Warn("invalid message", "peer", remote, "err", err)
+		n.write(conn, remote, n.errorMessage(fmt.Errorf("invalid message: %v", err)))
+		return
+	}
+	n.bc.logs.p2p.Debug("message received", "peer", msg.From, "type", msg.Type)
+	n.received(msg)
+	if reply := n.handleMessage(msg); reply != nil {
+		if err := n.write(conn, msg.From, reply); err != nil {
+			n.bc.logs.p2p.Debug("writing reply failed", "peer", msg.From, "type", reply.Type, "err", err)
+		}
+	}
+}
+
+// received records the message in the metrics and the event stream, and
+// remembers the height and head the sender announced.
+func (n *Network) received(msg NetMessage) {
+	n.bc.metrics.MessagesIn.With(msg.Type).Inc()
+	n.emit(NetEvent{Dir: "recv", Type: msg.Type, From: msg.From, To: n.listenAddr, Height: msg.Height, Head: msg.Head})
+	if msg.From == "" || msg.Head == "" {
+		return
+	}
+	height := n.bc.Height()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	st := n.status[msg.From]
+	if st == nil {
+		st = &PeerStatus{Addr: msg.From}
+		n.status[msg.From] = st
+	}
+	st.Height, st.Head, st.LastSeen = msg.Height, msg.Head, n.bc.Clock().Now()
+
+	best := 0
+	for _, st := range n.status {
+		if st.Height > best {
+			best = st.Height
+		}
+	}
+	lag := best - height
+	if lag < 0 {
+		lag = 0
+	}
+	n.bc.metrics.SyncLag.Set(float64(lag))
+}
+
+// handleMessage processes one incoming message and returns the reply, if
+// the message type has one.
+func (n *Network) handleMessage(msg NetMessage) *NetMessage {
+	switch msg.Type {
+	case MsgTypeHello:
+		var hello Hello
+		if err := json.Unmarshal(msg.Body, &hello); err != nil {
+			return n.errorMessage(err)
+		}
+		if hello.Genesis != n.bc.GenesisHash() {
+			n.bc.logs.p2p.Warn("peer on another network", "peer", msg.From, "genesis", hello.Genesis)
+			return n.errorMessage(ErrGenesisMismatch)
+		}
+		n.addPeers([]string{msg.From})
+		n.mu.Lock()
+		if st := n.status[msg.From]; st != nil {
+			st.RPC = hello.RPC
+		}
+		n.mu.Unlock()
+		if msg.Height > n.bc.Height() {
+			go n.syncFrom(msg.From)
+		}
+		return n.message(MsgTypePeerList, n.Peers())
+
+	case MsgTypeTx:
+		var tx Transaction
+		if err := json.Unmarshal(msg.Body, &tx); err != nil {
+			n.bc.logs.p2p.Warn("malformed tx message", "peer", msg.From, "err", err)
+			return nil
+		}
+		// The mempool checks chain ID, signature and nonce. Only
+		// transactions it accepts are forwarded, so gossip dies out.
+		if err := n.mempool.Add(tx); err != nil {
+			n.bc.logs.p2p.Debug("gossiped tx not forwarded", "peer", msg.From, "txid", tx.Hash(), "err", err)
+			return nil
+		}
+		n.broadcast(&msg, msg.From)
+		return nil
+
+	case MsgTypeBlock:
+		var block Block
+		if err := json.Unmarshal(msg.Body, &block); err != nil {
+			n.bc.logs.p2p.Warn("malformed block message", "peer", msg.From, "err", err)
+			return nil
+		}
+		// Like transactions, only blocks we import are forwarded.
+		switch err := n.bc.ImportBlock(block); {
+		case err == nil:
+			n.mempool.Update()
+			n.broadcast(&msg, msg.From)
+		case errors.Is(err, ErrKnownBlock):
+		case errors.Is(err, ErrFutureBlock), errors.Is(err, ErrUnknownParent):
+			go n.syncFrom(msg.From)
+		case errors.Is(err, ErrShorterFork):
+			n.reportConflict(block)
+			n.bc.logs.p2p.Debug("block on a shorter fork", "peer", msg.From, "height", block.Index, "hash", block.Hash)
+		default:
+			n.bc.logs.p2p.Warn("block rejected", "peer", msg.From, "height", block.Index, "hash", block.Hash, "err", err)
+		}
+		return nil
+
+	case MsgTypeEvidence:
+		var e Evidence
+		if err := json.Unmarshal(msg.Body, &e); err != nil {
+			n.bc.logs.p2p.Warn("malformed evidence message", "peer", msg.From, "err", err)
+			return nil
+		}
+		// Forwarded once, when it is new to us.
+		if err := n.bc.AddEvidence(e); err != nil {
+			if !errors.Is(err, ErrKnownEvidence) {
+				n.bc.logs.p2p.Warn("evidence rejected", "peer", msg.From, "err", err)
+			}
+			return nil
+		}
+		n.broadcast(&msg, msg.From)
+		return nil
+
+	case MsgTypeGetSnapshot:
+		return n.message(MsgTypeSnapshot, n.bc.LatestSnapshot())
+
+	case MsgTypeGetBlocks:
+		var from int
+		if err := json.Unmarshal(msg.Body, &from); err != nil {
+			return n.errorMessage(err)
+		}
+		return n.message(MsgTypeBlocks, n.bc.BlocksFrom(from))
+
+	default:
+		return n.errorMessage(fmt.Errorf("unknown message type %s", msg.Type))
+	}
+}
+
+func (n *Network) message(msgType string, body interface{}) *NetMessage {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return n.errorMessage(err)
+	}
+	head := n.bc.Head()
+	return &NetMessage{Type: msgType, From: n.listenAddr, Height: head.Index, Head: head.Hash, Body: raw}
+}
+
+func (n *Network) errorMessage(err error) *NetMessage {
+	raw, _ := json.Marshal(err.Error())
+	head := n.bc.Head()
+	return &NetMessage{Type: MsgTypeError, From: n.listenAddr, Height: head.Index, Head: head.Hash, Body: raw}
+}
+
+// ---------------- SENDING ----------------
+func writeMessage(conn net.Conn, msg *NetMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(b, '\n'))
+	return err
+}
+
+// write sends msg to peer on conn, through the fault rules, and records it.
+func (n *Network) write(conn net.Conn, peer string, msg *NetMessage) error {
+	plan := n.faults.plan(peer, msg.Type)
+	fault := strings.Join(plan.applied, ",")
+	if fault != "" {
+		n.bc.metrics.FaultsInjected.With(fault).Inc()
+		n.bc.logs.p2p.Debug("fault injected", "peer", peer, "type", msg.Type, "fault", fault)
+	}
+	if plan.drop {
+		n.emit(NetEvent{Dir: "send", Type: msg.Type, From: n.listenAddr, To: peer, Height: msg.Height, Head: msg.Head, Fault: fault})
+		return fmt.Errorf("%w: %s to %s", ErrDropped, msg.Type, peer)
+	}
+	if plan.delay > 0 {
+		select {
+		case <-n.bc.Clock().After(plan.delay):
+		case <-n.quit:
+			return net.ErrClosed
+		}
+		conn.SetDeadline(time.Now().Add(MessageTimeout))
+	}
+	if plan.corrupt {
+		msg = n.faults.corrupt(msg)
+	}
+	if err := n.deliver(conn, peer, msg, fault); err != nil {
+		return err
+	}
+	if plan.duplicate {
+		go func() {
+			conn, err := n.dial(peer)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(MessageTimeout))
+			n.deliver(conn, peer, msg, FaultDuplicate)
+		}()
+	}
+	return nil
+}
+
+// deliver writes msg to conn and records it.
+func (n *Network) deliver(conn net.Conn, peer string, msg *NetMessage, fault string) error {
+	if err := writeMessage(conn, msg); err != nil {
+		n.bc.metrics.SendFailures.Inc()
+		return err
+	}
+	n.bc.metrics.MessagesOut.With(msg.Type).Inc()
+	n.emit(NetEvent{Dir: "send", Type: msg.Type, From: n.listenAddr, To: peer, Height: msg.Height, Head: msg.Head, Fault: fault})
+	return nil
+}
+
+// dial connects to addr unless we are partitioned from it.
+func (n *Network) dial(addr string) (net.Conn, error) {
+	n.mu.Lock()
+	cut := n.cut[addr]
+	n.mu.Unlock()
+	if cut {
+		n.bc.metrics.SendFailures.Inc()
+		return nil, fmt.Errorf("%w: %s", ErrPartitioned, addr)
+	}
+	conn, err := net.DialTimeout("tcp", addr, DialTimeout)
+	if err != nil {
+		n.bc.metrics.SendFailures.Inc()
+	}
+	return conn, err
+}
+
+// send delivers a gossip message to addr without waiting for a reply.
+func (n *Network) send(addr string, msg *NetMessage) error {
+	conn, err := n.dial(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(MessageTimeout))
+	return n.write(conn, addr, msg)
+}
+
+// broadcast sends msg to every peer except skip, in the background. The
+// original sender is kept in msg.From.
+func (n *Network) broadcast(msg *NetMessage, skip string) {
+	for _, peer := range n.Peers() {
+		if peer == skip || peer == n.listenAddr {
+			continue
+		}
+		go func(peer string) {
+			if err := n.send(peer, msg); err != nil {
+				n.bc.logs.p2p.Debug("send failed", "peer", peer, "type", msg.Type, "err", err)
+			}
+		}(peer)
+	}
+}
+
+// BroadcastTx gossips a transaction to all peers.
+func (n *Network) BroadcastTx(tx Transaction) {
+	n.broadcast(n.message(MsgTypeTx, tx), "")
+}
+
+// BroadcastBlock gossips a block made by this node to all peers.
+func (n *Network) BroadcastBlock(block Block) {
+	n.broadcast(n.message(MsgTypeBlock, block), "")
+}
+
+// BroadcastEvidence gossips double-sign evidence to all peers.
+func (n *Network) BroadcastEvidence(e Evidence) {
+	n.broadcast(n.message(MsgTypeEvidence, e), "")
+}
+
+// reportConflict gossips evidence when block and ours at its height are
+// signed by the same validator.
+func (n *Network) reportConflict(block Block) {
+	if e, ok := n.bc.ReportConflict(block); ok {
+		n.BroadcastEvidence(e)
+	}
+}
+
+// request sends msg to addr and decodes a reply of type want into out.
+func (n *Network) request(addr string, msg *NetMessage, want string, out interface{}) error {
+	conn, err := n.dial(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(MessageTimeout))
+
+	if err := n.write(conn, addr, msg); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	var reply NetMessage
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return err
+	}
+	n.received(reply)
+	if reply.Type == MsgTypeError {
+		var text string
+		json.Unmarshal(reply.Body, &text)
+		return fmt.Errorf("peer %s: %s", addr, text)
+	}
+	if reply.Type != want {
+		return fmt.Errorf("peer %s: expected %s, got %s", addr, want, reply.Type)
+	}
+	return json.Unmarshal(reply.Body, out)
+}
+
+// ---------------- HELLO ----------------
+// helloLoop greets every peer now and then every HelloInterval.
+func (n *Network) helloLoop() {
+	ticker := n.bc.Clock().NewTicker(HelloInterval)
+	defer ticker.Stop()
+	for {
+		for _, peer := range n.Peers() {
+			go n.sayHello(peer)
+		}
+		select {
+		case <-n.quit:
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// sayHello greets peer, learns its peers and catches up if it is ahead.
+func (n *Network) sayHello(peer string) {
+	hello := Hello{Genesis: n.bc.GenesisHash(), RPC: n.rpcAddr}
+	var peers []string
+	if err := n.request(peer, n.message(MsgTypeHello, hello), MsgTypePeerList, &peers); err != nil {
+		n.bc.logs.p2p.Debug("hello failed", "peer", peer, "err", err)
+		return
+	}
+	n.addPeers(peers)
+	if st, ok := n.PeerStatus(peer); ok && st.Height > n.bc.Height() {
+		n.syncFrom(peer)
+	}
+}
+
+// PeerStatus returns what we last heard from peer.
+func (n *Network) PeerStatus(peer string) (PeerStatus, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	st, ok := n.status[peer]
+	if !ok {
+		return PeerStatus{Addr: peer}, false
+	}
+	return *st, true
+}
+
+// ---------------- PARTITIONS ----------------
+// A partition cuts this node off from some peers: nothing is sent to them
+// and nothing is requested from them. Cutting both sides of a link, as the
+// testnet launcher does, splits the network in two.
+
+// SetPartition replaces the set of peers we are cut off from; an empty
+// list heals the partition. Healed peers are greeted straight away so the
+// two sides catch up.
+func (n *Network) SetPartition(peers []string) {
+	cut := make(map[string]bool, len(peers))
+	for _, p := range peers {
+		cut[p] = true
+	}
+	n.mu.Lock()
+	healed := []string{}
+	for p := range n.cut {
+		if !cut[p] {
+			healed = append(healed, p)
+		}
+	}
+	n.cut = cut
+	n.mu.Unlock()
+	n.bc.logs.p2p.Info("partition set", "cut", len(peers), "healed", len(healed))
+	for _, p := range healed {
+		go n.sayHello(p)
+	}
+}
+
+// Partition lists the peers we are cut off from.
+func (n *Network) Partition() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]string, 0, len(n.cut))
+	for p := range n.cut {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ---------------- BLOCK SYNC ----------------
+// syncFrom fetches and imports the blocks peer has beyond our head. When
+// the peer's chain forked from ours it refetches from MaxReorgDepth blocks
+// back and reorganises onto it if it is longer. Only one sync runs at a
+// time; a call while another is running returns.
+func (n *Network) syncFrom(peer string) {
+	if !n.syncing.TryLock() {
+		return
+	}
+	defer n.syncing.Unlock()
+
+	var blocks []Block
+	head := n.bc.Head()
+	if err := n.request(peer, n.message(MsgTypeGetBlocks, head.Index+1), MsgTypeBlocks, &blocks); err != nil {
+		n.bc.logs.p2p.Debug("fetching blocks failed", "peer", peer, "err", err)
+		return
+	}
+	if len(blocks) > 0 && blocks[0].PrevHash != head.Hash {
+		n.reorgFrom(peer, head.Index)
+		return
+	}
+	imported := 0
+	for _, block := range blocks {
+		err := n.bc.ImportBlock(block)
+		if errors.Is(err, ErrKnownBlock) {
+			continue
+		}
+		if err != nil {
+			n.bc.logs.p2p.Warn("block rejected", "peer", peer, "height", block.Index, "hash", block.Hash, "err", err)
+			break
+		}
+		imported++
+	}
+	if imported > 0 {
+		n.mempool.Update()
+		n.bc.logs.p2p.Info("synced blocks", "peer", peer, "count", imported, "height", n.bc.Height())
+	}
+}
+
+// reorgFrom switches to peer's chain after a fork. Transactions of the
+// dropped blocks go back to the mempool unless the new chain has them.
+func (n *Network) reorgFrom(peer string, height int) {
+	from := height + 1 - MaxReorgDepth
+	if from < 1 {
+		from = 1
+	}
+	var blocks []Block
+	if err := n.request(peer, n.message(MsgTypeGetBlocks, from), MsgTypeBlocks, &blocks); err != nil {
+		n.bc.logs.p2p.Debug("fetching blocks failed", "peer", peer, "err", err)
+		return
+	}
+	dropped, err := n.bc.Reorg(blocks)
+	if err != nil {
+		if errors.Is(err, ErrShorterFork) {
+			for _, b := range blocks {
+				n.reportConflict(b)
+			}
+		} else if !errors.Is(err, ErrKnownBlock) {
+			n.bc.logs.p2p.Warn("fork rejected", "peer", peer, "err", err)
+		}
+		return
+	}
+	for _, b := range dropped {
+		n.reportConflict(b)
+	}
+	n.mempool.Update()
+	for _, b := range dropped {
+		for _, tx := range b.Txs {
+			n.mempool.Add(tx)
+		}
+	}
+	n.bc.logs.p2p.Info("switched to peer's chain", "peer", peer, "dropped", len(dropped), "height", n.bc.Height())
+}
+
+// ---------------- SNAPSHOT SYNC ----------------
+// SnapshotSync bootstraps a fresh chain from peer: it downloads the
+// peer's blocks and latest state snapshot and hands both to
+// ImportSnapshot, which verifies them before anything is kept.
+func (n *Network) SnapshotSync(peer string) error {
+	if n.bc.Height() > 0 {
+		return errors.New("snapshot sync needs a chain with only a genesis block")
+	}
+
+	var snap Snapshot
+	if err := n.request(peer, n.message(MsgTypeGetSnapshot, nil), MsgTypeSnapshot, &snap); err != nil {
+		return err
+	}
+	var blocks []Block
+	if err := n.request(peer, n.message(MsgTypeGetBlocks, 0), MsgTypeBlocks, &blocks); err != nil {
+		return err
+	}
+	n.bc.logs.p2p.Info("snapshot received", "peer", peer, "height", snap.Height, "blocks", len(blocks))
+	return n.bc.ImportSnapshot(blocks, &snap)
+}
+
+// ---------------- EVENTS ----------------
+// NetEvent is one message sent or received by this node, for watchers
+// such as the network visualiser.
+type NetEvent struct {
+	Time   time.Time `json:"time"`
+	Dir    string    `json:"dir"` // "send" or "recv"
+	Type   string    `json:"type"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Height int       `json:"height"` // of the sender
+	Head   string    `json:"head,omitempty"`
+	Fault  string    `json:"fault,omitempty"` // fault actions applied to a send
+}
+
+// Watch returns a channel of network events and a function that stops
+// them. Events are dropped for a watcher that falls behind.
+func (n *Network) Watch() (<-chan NetEvent, func()) {
+	ch := make(chan NetEvent, 64)
+	n.mu.Lock()
+	n.watches[ch] = struct{}{}
+	n.mu.Unlock()
+	return ch, func() {
+		n.mu.Lock()
+		delete(n.watches, ch)
+		n.mu.Unlock()
+	}
+}
+
+func (n *Network) emit(ev NetEvent) {
+	ev.Time = n.bc.Clock().Now()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.watches {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// NetworkStatus is this node's view of the network.
+type NetworkStatus struct {
+	Self  PeerStatus   `json:"self"`
+	Peers []PeerStatus `json:"peers"`
+}
+
+// Status returns our own height and head and what we last heard from
+// each peer.
+func (n *Network) Status() NetworkStatus {
+	head := n.bc.Head()
+	out := NetworkStatus{Self: PeerStatus{
+		Addr: n.listenAddr, RPC: n.rpcAddr, Height: head.Index, Head: head.Hash, LastSeen: n.bc.Clock().Now(),
+	}}
+	for _, peer := range n.Peers() {
+		st, _ := n.PeerStatus(peer)
+		out.Peers = append(out.Peers, st)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package node
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a localhost address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// startNetwork starts a node with an in-memory chain and the given peers.
+// Alice and bob are funded at genesis.
+func startNetwork(t *testing.T, addr string, peers ...string) *Network {
+	t.Helper()
+	bc := NewBlockchainWithConfig(fundedSpec(100, "alice", "bob"))
+	n := NewNetwork(addr, bc, NewMempool(bc, DefaultMempoolConfig), peers)
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(n.Stop)
+	return n
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestBlockGossipAndCatchUp(t *testing.T) {
+	addrA, addrB, addrC := freeAddr(t), freeAddr(t), freeAddr(t)
+	a := startNetwork(t, addrA)
+	events, stop := a.Watch()
+	defer stop()
+
+	// B knows A; A learns about B from its HELLO.
+	b := startNetwork(t, addrB, addrA)
+	waitFor(t, "A to learn about B", func() bool { return len(a.Peers()) == 1 })
+
+	block, err := a.bc.CommitBlock("one", []Transaction{transfer("alice", "bob", 10, 0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.BroadcastBlock(block)
+	waitFor(t, "B to import the block", func() bool { return b.bc.Height() == 1 })
+	if b.bc.Head().Hash != block.Hash {
+		t.Fatal("B has a different head")
+	}
+
+	// C starts late and catches up from the heights announced in HELLO.
+	a.bc.CommitBlock("two", nil)
+	c := startNetwork(t, addrC, addrB)
+	waitFor(t, "C to catch up", func() bool { return c.bc.Height() >= 1 })
+	st, ok := c.PeerStatus(addrB)
+	if !ok || st.Head != b.bc.Head().Hash {
+		t.Fatalf("C's view of B: %+v", st)
+	}
+
+	seen := map[string]bool{}
+	for len(events) > 0 {
+		ev := <-events
+		seen[ev.Dir+" "+ev.Type] = true
+	}
+	for _, want := range []string{"recv HELLO", "send PEER_LIST", "send BLOCK"} {
+		if !seen[want] {
+			t.Errorf("no %q event, got %v", want, seen)
+		}
+	}
+}
+
+func TestImportBlockRejectsTampering(t *testing.T) {
+	src, dst := NewBlockchainWithConfig(fundedSpec(100, "alice")), NewBlockchainWithConfig(fundedSpec(100, "alice"))
+	block, _ := src.CommitBlock("one", []Transaction{transfer("alice", "bob", 10, 0, 0)})
+
+	bad := block
+	bad.StateRoot = "00"
+	bad.Hash = CalculateHash(bad)
+	if err := dst.ImportBlock(bad); !errors.Is(err, ErrInvalidBlock) {
+		t.Fatalf("expected ErrInvalidBlock, got %v", err)
+	}
+	next, _ := src.CommitBlock("two", nil)
+	if err := dst.ImportBlock(next); !errors.Is(err, ErrFutureBlock) {
+		t.Fatalf("expected ErrFutureBlock, got %v", err)
+	}
+	if err := dst.ImportBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.ImportBlock(block); !errors.Is(err, ErrKnownBlock) {
+		t.Fatalf("expected ErrKnownBlock, got %v", err)
+	}
+}
+
+func TestImportBlockBelowGenesis(t *testing.T) {
+	bc := NewBlockchain()
+	n := NewNetwork("127.0.0.1:0", bc, NewMempool(bc, DefaultMempoolConfig), nil)
+	for _, index := range []int{-1, 0} {
+		block := Block{Index: index, PrevHash: bc.Head().Hash}
+		block.Hash = CalculateHash(block)
+		if err := bc.ImportBlock(block); !errors.Is(err, ErrInvalidBlock) {
+			t.Fatalf("block %d: expected ErrInvalidBlock, got %v", index, err)
+		}
+		n.handleMessage(*n.message(MsgTypeBlock, block))
+	}
+	if bc.Height() != 0 {
+		t.Fatal("chain changed")
+	}
+}
+
+func TestSyncSwitchesToLongerFork(t *testing.T) {
+	addrA, addrB := freeAddr(t), freeAddr(t)
+	b := startNetwork(t, addrB)
+	b.bc.CommitBlock("b1", []Transaction{transfer("bob", "dave", 10, 0, 0)})
+	b.bc.CommitBlock("b2", nil)
+
+	// A built its own block 1 while apart from B.
+	bc := NewBlockchainWithConfig(fundedSpec(100, "alice", "bob"))
+	mp := NewMempool(bc, DefaultMempoolConfig)
+	if _, err := bc.CommitBlock("a1", []Transaction{transfer("alice", "carol", 1, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewNetwork(addrA, bc, mp, []string{addrB})
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(a.Stop)
+
+	waitFor(t, "A to switch to B's chain", func() bool { return a.bc.Head().Hash == b.bc.Head().Hash })
+	if a.bc.State().Balance(addr("dave")) != 10 || a.bc.State().Balance(addr("carol")) != 0 {
+		t.Fatal("A's state does not follow B's chain")
+	}
+	// The dropped transfer is pending again.
+	if pending := mp.Pending(); len(pending) != 1 || pending[0].To != addr("carol") {
+		t.Fatalf("pending after the reorg: %+v", pending)
+	}
+}
+
+func TestPartitionAndHeal(t *testing.T) {
+	addrA, addrB := freeAddr(t), freeAddr(t)
+	a := startNetwork(t, addrA)
+	b := startNetwork(t, addrB, addrA)
+	waitFor(t, "A to learn about B", func() bool { return len(a.Peers()) == 1 })
+
+	a.SetPartition([]string{addrB})
+	b.SetPartition([]string{addrA})
+	block, _ := a.bc.CommitBlock("one", nil)
+	a.BroadcastBlock(block)
+	if err := a.send(addrB, &NetMessage{Type: MsgTypeBlock}); !errors.Is(err, ErrPartitioned) {
+		t.Fatalf("send across the partition: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if b.bc.Height() != 0 {
+		t.Fatal("B got a block across the partition")
+	}
+
+	a.SetPartition(nil)
+	b.SetPartition(nil)
+	waitFor(t, "B to catch up after the heal", func() bool { return b.bc.Height() == 1 })
+}
@@ -0,0 +1,163 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ---------------- QUERIES ----------------
+// Lookups of blocks, transactions and account history, shared by the RPC
+// API and the explorer. They use the indexes when enabled and scan the
+// chain otherwise.
+
+// Page sizes for paginated queries.
+const (
+	DefaultPageSize = 25
+	MaxPageSize     = 500
+)
+
+// TxLocation is a transaction together with the block that includes it.
+type TxLocation struct {
+	Tx        Transaction `json:"tx"`
+	Hash      string      `json:"hash"`
+	Height    int         `json:"height"`
+	BlockHash string      `json:"block_hash"`
+	Index     int         `json:"index"` // position in the block
+	Time      string      `json:"time"`
+}
+
+func locate(block Block, i int) TxLocation {
+	tx := block.Txs[i]
+	return TxLocation{Tx: tx, Hash: tx.Hash(), Height: block.Index, BlockHash: block.Hash, Index: i, Time: block.Timestamp.String()}
+}
+
+// HistoryPage is one page of an address history, newest first.
+type HistoryPage struct {
+	Txs  []TxLocation `json:"txs"`
+	Next string       `json:"next,omitempty"` // cursor of the next page; empty on the last one
+}
+
+// BlockByHeight returns the block at height.
+func (bc *Blockchain) BlockByHeight(height int) (Block, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if height < 0 || height >= len(bc.Blocks) {
+		return Block{}, fmt.Errorf("%w: block %d", ErrNotFound, height)
+	}
+	return bc.Blocks[height], nil
+}
+
+// BlockByHash returns the block with the given hash.
+func (bc *Blockchain) BlockByHash(hash string) (Block, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if bc.index.Blocks != nil {
+		if h, ok := bc.index.Blocks[hash]; ok {
+			return bc.Blocks[h], nil
+		}
+	} else {
+		for _, b := range bc.Blocks {
+			if b.Hash == hash {
+				return b, nil
+			}
+		}
+	}
+	return Block{}, fmt.Errorf("%w: block %s", ErrNotFound, hash)
+}
+
+// FindBlock returns the block for a height or a block hash.
+func (bc *Blockchain) FindBlock(id string) (Block, error) {
+	if height, err := strconv.Atoi(id); err == nil {
+		return bc.BlockByHeight(height)
+	}
+	return bc.BlockByHash(id)
+}
+
+// TxByHash returns the first included transaction with the given hash.
+func (bc *Blockchain) TxByHash(hash string) (TxLocation, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if bc.index.Txs != nil {
+		if ref, ok := bc.index.Txs[hash]; ok {
+			return locate(bc.Blocks[ref.Height], ref.Index), nil
+		}
+	} else {
+		for _, b := range bc.Blocks {
+			for i, tx := range b.Txs {
+				if tx.Hash() == hash {
+					return locate(b, i), nil
+				}
+			}
+		}
+	}
+	return TxLocation{}, fmt.Errorf("%w: transaction %s", ErrNotFound, hash)
+}
+
+// AddressHistory returns up to limit included transactions sent or
+// received by address, newest first, starting after cursor ("" for the
+// newest). limit <= 0 means DefaultPageSize.
+func (bc *Blockchain) AddressHistory(address, cursor string, limit int) (HistoryPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	var after *TxRef
+	if cursor != "" {
+		ref, err := decodeCursor(cursor)
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		after = &ref
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	page := HistoryPage{Txs: []TxLocation{}}
+	add := func(ref TxRef) bool {
+		if after != nil && !after.before(ref) {
+			return true
+		}
+		if len(page.Txs) == limit {
+			page.Next = encodeCursor(TxRef{page.Txs[limit-1].Height, page.Txs[limit-1].Index})
+			return false
+		}
+		page.Txs = append(page.Txs, locate(bc.Blocks[ref.Height], ref.Index))
+		return true
+	}
+
+	if bc.index.Addresses != nil {
+		refs := bc.index.Addresses[address]
+		for i := len(refs) - 1; i >= 0 && add(refs[i]); i-- {
+		}
+		return page, nil
+	}
+	for h := len(bc.Blocks) - 1; h >= 0; h-- {
+		txs := bc.Blocks[h].Txs
+		for i := len(txs) - 1; i >= 0; i-- {
+			if txs[i].From == address || txs[i].To == address {
+				if !add(TxRef{h, i}) {
+					return page, nil
+				}
+			}
+		}
+	}
+	return page, nil
+}
+
+// LatestBlocks returns up to n blocks, newest first.
+func (bc *Blockchain) LatestBlocks(n int) []Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	var out []Block
+	for h := len(bc.Blocks) - 1; h >= 0 && len(out) < n; h-- {
+		out = append(out, bc.Blocks[h])
+	}
+	return out
+}
+
+// IsAddress reports whether s is a well-formed account address.
+func IsAddress(s string) bool {
+	return isAddress(s)
+}
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestChainLookups(t *testing.T) {
+	bc := NewBlockchainWithConfig(fundedSpec(100, "alice"))
+	bc.CommitBlock("first", []Transaction{transfer("alice", "carol", 10, 1, 0)})
+	tx := transfer("alice", "bob", 5, 1, 1)
+	block, err := bc.CommitBlock("send", []Transaction{tx})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{strconv.Itoa(block.Index), block.Hash} {
+		if b, err := bc.FindBlock(id); err != nil || b.Hash != block.Hash {
+			t.Fatalf("FindBlock(%s): %v", id, err)
+		}
+	}
+	if _, err := bc.FindBlock("7"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	loc, err := bc.TxByHash(tx.Hash())
+	if err != nil || loc.Height != 2 || loc.Index != 0 || loc.BlockHash != block.Hash {
+		t.Fatalf("TxByHash: %+v, %v", loc, err)
+	}
+	if hist, err := bc.AddressHistory(addr("alice"), "", 0); err != nil || len(hist.Txs) != 2 || hist.Txs[0].Hash != tx.Hash() {
+		t.Fatalf("alice's history, newest first: %+v, %v", hist, err)
+	}
+	if hist, _ := bc.AddressHistory(addr("bob"), "", 0); len(hist.Txs) != 1 || hist.Next != "" {
+		t.Fatalf("bob's history: %+v", hist)
+	}
+	if latest := bc.LatestBlocks(2); len(latest) != 2 || latest[0].Index != 2 {
+		t.Fatalf("LatestBlocks: %+v", latest)
+	}
+}
@@ -0,0 +1,128 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ---------------- REORG ----------------
+// The longest valid chain wins. When a peer's chain forks from ours and
+// is longer, Reorg drops our blocks after the fork and takes the peer's.
+
+// MaxReorgDepth is the most blocks a reorg may drop.
+const MaxReorgDepth = 64
+
+// Reorg switches to the chain made of our blocks up to the parent of
+// blocks[0] followed by blocks, which must have consecutive heights.
+// Blocks we already have at the start are skipped. The new chain must be
+// longer than ours and every new block is fully checked before anything
+// changes. It returns the dropped blocks, newest last, so their
+// transactions can go back to the mempool.
+func (bc *Blockchain) Reorg(blocks []Block) ([]Block, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	for i, block := range blocks {
+		if block.Index < 0 || (i > 0 && block.Index != blocks[i-1].Index+1) {
+			return nil, fmt.Errorf("%w: block %d at position %d of the fork", ErrInvalidBlock, block.Index, i)
+		}
+	}
+	for len(blocks) > 0 && blocks[0].Index < len(bc.Blocks) && bc.Blocks[blocks[0].Index].Hash == blocks[0].Hash {
+		blocks = blocks[1:]
+	}
+	if len(blocks) == 0 {
+		return nil, ErrKnownBlock
+	}
+	head := bc.Blocks[len(bc.Blocks)-1]
+	fork := blocks[0].Index
+	switch {
+	case fork < 1 || fork > len(bc.Blocks) || bc.Blocks[fork-1].Hash != blocks[0].PrevHash:
+		return nil, fmt.Errorf("%w: block %d", ErrUnknownParent, blocks[0].Index)
+	case blocks[len(blocks)-1].Index <= head.Index:
+		return nil, fmt.Errorf("%w: fork ends at %d, our head is %d", ErrShorterFork, blocks[len(blocks)-1].Index, head.Index)
+	case head.Index-fork+1 > MaxReorgDepth:
+		return nil, fmt.Errorf("%w: %d blocks", ErrReorgTooDeep, head.Index-fork+1)
+	}
+
+	base, err := bc.stateAt(fork - 1)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*State, len(blocks))
+	parent, state := bc.Blocks[fork-1], base
+	recent := append([]Block(nil), bc.recentBlocks(fork-1)...)
+	now := bc.clock.Now()
+	for i, block := range blocks {
+		if err := bc.checkTimestamp(recent, block, now); err != nil {
+			bc.metrics.InvalidBlocks.Inc()
+			return nil, err
+		}
+		recent = append(recent, block)
+		if state, err = bc.checkBlock(parent, state, block); err != nil {
+			return nil, err
+		}
+		states[i], parent = state, block
+	}
+
+	dropped := bc.truncate(fork, base)
+	for i, block := range blocks {
+		if err := bc.extend(block, states[i]); err != nil {
+			return dropped, err
+		}
+	}
+	bc.metrics.Reorgs.Inc()
+	newHead := blocks[len(blocks)-1]
+	bc.logs.chain.Warn("chain reorganised", "fork", fork, "dropped", len(dropped), "height", newHead.Index, "hash", newHead.Hash)
+	bc.prune()
+	if err := bc.save(); err != nil {
+		bc.logs.chain.Error("saving chain failed", "height", newHead.Index, "err", err)
+		return dropped, err
+	}
+	return dropped, nil
+}
+
+// Rewind drops the blocks above height, for instance to get rid of
+// blocks that fail verification before syncing them again from a peer.
+// It returns the dropped blocks, newest last.
+func (bc *Blockchain) Rewind(height int) ([]Block, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if height < 0 || height >= len(bc.Blocks) {
+		return nil, fmt.Errorf("%w: block %d", ErrNotFound, height)
+	}
+	state, err := bc.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	dropped := bc.truncate(height+1, state)
+	bc.metrics.Height.Set(float64(height))
+	bc.logs.chain.Warn("chain rewound", "height", height, "dropped", len(dropped))
+	return dropped, bc.save()
+}
+
+// truncate drops the blocks from height fork on, with their index
+// entries, states and snapshots; state is the state after fork-1. The
+// caller holds bc.mu.
+func (bc *Blockchain) truncate(fork int, state *State) []Block {
+	dropped := append([]Block(nil), bc.Blocks[fork:]...)
+	for i := len(dropped) - 1; i >= 0; i-- {
+		bc.index.remove(dropped[i], dropped[i].PrevHash)
+		delete(bc.history, dropped[i].Index)
+		bc.removeSnapshot(dropped[i].Index)
+	}
+	bc.Blocks = bc.Blocks[:fork]
+	bc.state = state
+	return dropped
+}
+
+// removeSnapshot deletes the snapshot file at height, if there is one.
+func (bc *Blockchain) removeSnapshot(height int) {
+	if bc.path == "" {
+		return
+	}
+	err := os.Remove(filepath.Join(bc.snapshotDir(), fmt.Sprintf("state-%d.json", height)))
+	if err != nil && !os.IsNotExist(err) {
+		bc.logs.chain.Warn("removing snapshot failed", "height", height, "err", err)
+	}
+}
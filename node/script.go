@@ -0,0 +1,153 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ---------------- SCRIPTS ----------------
+// A script is a file of console commands, one per line, with two
+// additions:
+//
+//	$w1 = create_wallet 100       stores the command's value in $w1
+//	expect balance $w1 == 90      fails the script unless the value matches
+//	expect error send $w1 $w1 5   fails the script unless the command fails
+//
+// Variables may be used anywhere in a line. Comparisons are ==, !=, <, <=,
+// > and >=; numbers compare as numbers, anything else as text. Lines
+// starting with # are comments, and `echo <text>` prints text. A command
+// that fails outside `expect error` ends the script.
+
+// ErrScriptFailed wraps the first failure of a script.
+var ErrScriptFailed = errors.New("script failed")
+
+var (
+	assignLine = regexp.MustCompile(`^\$(\w+)\s*=\s*(.+)$`)
+	expectLine = regexp.MustCompile(`^expect\s+(.+?)\s+(==|!=|<=|>=|<|>)\s+(\S+)$`)
+	scriptVar  = regexp.MustCompile(`\$(\w+)`)
+)
+
+// RunScript runs the script read from r on the console and writes the
+// commands and their output to out.
+func RunScript(c *Console, r io.Reader, out io.Writer) error {
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: line %d: %s: %s", ErrScriptFailed, n, line, fmt.Sprintf(format, args...))
+		}
+
+		if m := assignLine.FindStringSubmatch(line); m != nil {
+			cmd, err := expandVars(m[2], vars)
+			if err != nil {
+				return fail("%v", err)
+			}
+			fmt.Fprintf(out, "> %s\n", line)
+			res, err := c.Exec(cmd)
+			if err != nil {
+				return fail("%v", err)
+			}
+			printResult(out, res)
+			vars[m[1]] = fmt.Sprint(res.Value)
+			continue
+		}
+		expanded, err := expandVars(line, vars)
+		if err != nil {
+			return fail("%v", err)
+		}
+		fmt.Fprintf(out, "> %s\n", line)
+
+		switch {
+		case expanded == "echo" || strings.HasPrefix(expanded, "echo "):
+			fmt.Fprintln(out, strings.TrimSpace(strings.TrimPrefix(expanded, "echo")))
+
+		case strings.HasPrefix(expanded, "expect error "):
+			_, err := c.Exec(strings.TrimPrefix(expanded, "expect error "))
+			if err == nil {
+				return fail("command succeeded")
+			}
+			fmt.Fprintln(out, "✅ failed as expected:", err)
+
+		case strings.HasPrefix(expanded, "expect "):
+			m := expectLine.FindStringSubmatch(expanded)
+			if m == nil {
+				return fail("want: expect <command> <op> <value>")
+			}
+			res, err := c.Exec(m[1])
+			if err != nil {
+				return fail("%v", err)
+			}
+			got := fmt.Sprint(res.Value)
+			if !compare(got, m[2], m[3]) {
+				return fail("got %s", got)
+			}
+			fmt.Fprintf(out, "✅ %s %s %s\n", got, m[2], m[3])
+
+		default:
+			res, err := c.Exec(expanded)
+			if err != nil {
+				return fail("%v", err)
+			}
+			printResult(out, res)
+		}
+	}
+	return scanner.Err()
+}
+
+// expandVars replaces every $name in s by its value.
+func expandVars(s string, vars map[string]string) (string, error) {
+	var err error
+	out := scriptVar.ReplaceAllStringFunc(s, func(v string) string {
+		val, ok := vars[v[1:]]
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable %s", v)
+		}
+		return val
+	})
+	return out, err
+}
+
+func printResult(out io.Writer, res *CommandResult) {
+	if res.Text != "" {
+		fmt.Fprintln(out, res.Text)
+	}
+}
+
+// compare applies op to got and want, as numbers when both are integers.
+func compare(got, op, want string) bool {
+	order := strings.Compare(got, want)
+	g, err1 := strconv.ParseInt(got, 10, 64)
+	w, err2 := strconv.ParseInt(want, 10, 64)
+	if err1 == nil && err2 == nil {
+		order = 0
+		if g < w {
+			order = -1
+		} else if g > w {
+			order = 1
+		}
+	}
+	switch op {
+	case "==":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newScriptConsole returns a console whose only wallet, faucet, holds the
+// genesis alloc, so create_wallet can fund new wallets from it.
+func newScriptConsole(t *testing.T) *Console {
+	t.Helper()
+	bc := NewBlockchainWithConfig(fundedSpec(1000, "faucet"))
+	bc.Wallets = []*Wallet{testWallet("faucet")}
+	bc.walletsPath = filepath.Join(t.TempDir(), WalletsFile)
+	return NewConsole(bc, NewMempool(bc, DefaultMempoolConfig), nil)
+}
+
+func TestShippedScripts(t *testing.T) {
+	paths, _ := filepath.Glob(filepath.Join("..", "scripts", "*.proco"))
+	if len(paths) == 0 {
+		t.Fatal("no scripts found")
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Like run-script, on a node opened from an empty data directory.
+		cfg, err := LoadNodeConfig(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := OpenNode(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out strings.Builder
+		if err := RunScript(NewConsole(n.Chain, n.Mempool, nil), f, &out); err != nil {
+			t.Errorf("%s: %v\n%s", path, err, out.String())
+		}
+		n.Stop()
+		f.Close()
+	}
+}
+
+func TestScriptFailures(t *testing.T) {
+	for name, script := range map[string]string{
+		"assertion":          "$w = create_wallet 10\nexpect balance $w == 11",
+		"undefined var":      "balance $nobody",
+		"failing command":    "send a b 1",
+		"unexpected success": "expect error height",
+	} {
+		err := RunScript(newScriptConsole(t), strings.NewReader(script), io.Discard)
+		if !errors.Is(err, ErrScriptFailed) {
+			t.Errorf("%s: expected failure, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package node
+
+import (
+	"fmt"
+)
+
+// ---------------- NODE ----------------
+// Node is a full node opened from a data directory: chain, wallets,
+// mempool and p2p. The RPC server and the console are attached to it by
+// the caller.
+type Node struct {
+	Config  *NodeConfig
+	Spec    *Config
+	Chain   *Blockchain
+	Mempool *Mempool
+	Network *Network
+
+	restored []Transaction // journaled txs to announce on Start
+}
+
+// OpenNode loads everything in cfg.DataDir but does not touch the network.
+func OpenNode(cfg *NodeConfig) (*Node, error) {
+	spec, err := cfg.LoadGenesis()
+	if err != nil {
+		return nil, fmt.Errorf("loading genesis: %w", err)
+	}
+	bc, err := LoadBlockchain(cfg.Path(ChainFile), spec)
+	if err != nil {
+		return nil, fmt.Errorf("loading blockchain: %w", err)
+	}
+	opts := DefaultChainOptions
+	if cfg.SnapshotInterval > 0 {
+		opts.SnapshotInterval = cfg.SnapshotInterval
+	}
+	opts.PruneDepth = cfg.PruneDepth
+	bc.SetOptions(opts)
+	bc.SetProposer(cfg.Proposer)
+	if cfg.Indexes != nil {
+		if err := bc.SetIndexes(cfg.Indexes); err != nil {
+			return nil, err
+		}
+	}
+	if err := bc.LoadWallets(cfg.Path(WalletsFile)); err != nil {
+		return nil, fmt.Errorf("loading wallets: %w", err)
+	}
+	// The dev key is public, so a chain that funds it may spend from it.
+	if dev := DevWallet(); spec.allocated(dev.Address) && FindWallet(bc, dev.Address) == nil {
+		if err := bc.AddWallet(dev); err != nil {
+			return nil, fmt.Errorf("adding the dev wallet: %w", err)
+		}
+	}
+
+	mp := NewMempool(bc, DefaultMempoolConfig)
+	restored, err := mp.LoadJournal(cfg.Path(MempoolJournalFile))
+	if err != nil {
+		return nil, fmt.Errorf("loading mempool journal: %w", err)
+	}
+
+	return &Node{
+		Config:   cfg,
+		Spec:     spec,
+		Chain:    bc,
+		Mempool:  mp,
+		Network:  NewNetwork(cfg.P2PAddr, bc, mp, cfg.Peers),
+		restored: restored,
+	}, nil
+}
+
+// Start opens the p2p listener. Pending transactions from before a restart
+// are announced again, since peers may have dropped them meanwhile.
+func (n *Node) Start() error {
+	if err := n.Network.Start(); err != nil {
+		return err
+	}
+	if len(n.restored) > 0 {
+		n.Chain.logs.mempool.Info("restored pending transactions from the journal", "count", len(n.restored))
+		for _, tx := range n.restored {
+			n.Network.BroadcastTx(tx)
+		}
+		n.restored = nil
+	}
+	return nil
+}
+
+// Stop closes the p2p listener and the mempool journal.
+func (n *Node) Stop() {
+	n.Network.Stop()
+	n.Mempool.CloseJournal()
+}
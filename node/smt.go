@@ -0,0 +1,178 @@
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+)
+
+// A sparse Merkle tree has one leaf slot for every possible 256-bit key.
+// Almost every slot is empty, so empty subtrees are represented by a
+// precomputed default hash per height and only occupied paths are hashed.
+
+const smtDepth = 256
+
+// smtEmpty[h] is the root of an empty subtree of height h.
+var smtEmpty = func() [smtDepth + 1][]byte {
+	var e [smtDepth + 1][]byte
+	e[0] = make([]byte, sha256.Size)
+	for h := 1; h <= smtDepth; h++ {
+		e[h] = smtHashNode(e[h-1], e[h-1])
+	}
+	return e
+}()
+
+// ---------------- MERKLE PROOF ----------------
+// Bitmap has bit i set when the sibling at depth i (0 = just below the root)
+// is non-empty; Siblings lists those non-empty hashes from the top down.
+type MerkleProof struct {
+	Bitmap   string   `json:"Bitmap"`
+	Siblings []string `json:"Siblings"`
+}
+
+// ---------------- SPARSE MERKLE TREE ----------------
+type SparseMerkleTree struct {
+	leaves map[[32]byte][]byte // key -> leaf hash
+}
+
+type smtLeaf struct {
+	key  [32]byte
+	hash []byte
+}
+
+func NewSparseMerkleTree() *SparseMerkleTree {
+	return &SparseMerkleTree{leaves: make(map[[32]byte][]byte)}
+}
+
+// Update stores value under key. A nil value removes the key.
+func (t *SparseMerkleTree) Update(key [32]byte, value []byte) {
+	if value == nil {
+		delete(t.leaves, key)
+		return
+	}
+	t.leaves[key] = smtHashLeaf(key, value)
+}
+
+func (t *SparseMerkleTree) Root() []byte {
+	return smtRoot(t.sorted(), 0)
+}
+
+// Prove returns the siblings on the path to key. The same proof shows
+// inclusion when key is set and non-inclusion when it is not.
+func (t *SparseMerkleTree) Prove(key [32]byte) MerkleProof {
+	leaves := t.sorted()
+	bitmap := make([]byte, smtDepth/8)
+	proof := MerkleProof{Siblings: []string{}}
+
+	for depth := 0; depth < smtDepth; depth++ {
+		split := smtSplit(leaves, depth)
+		var sibling []smtLeaf
+		if smtBit(key, depth) == 0 {
+			sibling, leaves = leaves[split:], leaves[:split]
+		} else {
+			sibling, leaves = leaves[:split], leaves[split:]
+		}
+		if len(sibling) == 0 {
+			continue
+		}
+		bitmap[depth/8] |= 1 << (7 - uint(depth%8))
+		proof.Siblings = append(proof.Siblings, hex.EncodeToString(smtRoot(sibling, depth+1)))
+	}
+	proof.Bitmap = hex.EncodeToString(bitmap)
+	return proof
+}
+
+func (t *SparseMerkleTree) sorted() []smtLeaf {
+	out := make([]smtLeaf, 0, len(t.leaves))
+	for k, h := range t.leaves {
+		out = append(out, smtLeaf{key: k, hash: h})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].key[:], out[j].key[:]) < 0
+	})
+	return out
+}
+
+// ---------------- VERIFY PROOF ----------------
+// VerifyMerkleProof checks that key holds value (or is empty when value is
+// nil) in the tree with the given root. It needs nothing but its arguments.
+func VerifyMerkleProof(root []byte, key [32]byte, value []byte, proof MerkleProof) error {
+	bitmap, err := hex.DecodeString(proof.Bitmap)
+	if err != nil || len(bitmap) != smtDepth/8 {
+		return errors.New("malformed proof bitmap")
+	}
+
+	current := smtEmpty[0]
+	if value != nil {
+		current = smtHashLeaf(key, value)
+	}
+
+	next := len(proof.Siblings) - 1
+	for depth := smtDepth - 1; depth >= 0; depth-- {
+		sibling := smtEmpty[smtDepth-depth-1]
+		if bitmap[depth/8]&(1<<(7-uint(depth%8))) != 0 {
+			if next < 0 {
+				return errors.New("proof has too few siblings")
+			}
+			sibling, err = hex.DecodeString(proof.Siblings[next])
+			if err != nil {
+				return errors.New("malformed proof sibling")
+			}
+			next--
+		}
+		if smtBit(key, depth) == 0 {
+			current = smtHashNode(current, sibling)
+		} else {
+			current = smtHashNode(sibling, current)
+		}
+	}
+	if next != -1 {
+		return errors.New("proof has unused siblings")
+	}
+	if !bytes.Equal(current, root) {
+		return errors.New("proof does not match root")
+	}
+	return nil
+}
+
+// ---------------- HELPERS ----------------
+func smtRoot(leaves []smtLeaf, depth int) []byte {
+	if len(leaves) == 0 {
+		return smtEmpty[smtDepth-depth]
+	}
+	if depth == smtDepth {
+		return leaves[0].hash
+	}
+	split := smtSplit(leaves, depth)
+	return smtHashNode(smtRoot(leaves[:split], depth+1), smtRoot(leaves[split:], depth+1))
+}
+
+// smtSplit returns the index of the first leaf whose bit at depth is 1.
+// leaves must be sorted and share the same prefix above depth.
+func smtSplit(leaves []smtLeaf, depth int) int {
+	return sort.Search(len(leaves), func(i int) bool {
+		return smtBit(leaves[i].key, depth) == 1
+	})
+}
+
+func smtBit(key [32]byte, depth int) byte {
+	return (key[depth/8] >> (7 - uint(depth%8))) & 1
+}
+
+func smtHashLeaf(key [32]byte, value []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(key[:])
+	h.Write(value)
+	return h.Sum(nil)
+}
+
+func smtHashNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
@@ -0,0 +1,297 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------- CHAIN OPTIONS ----------------
+// ChainOptions control how much historical state a node keeps.
+type ChainOptions struct {
+	SnapshotInterval int // write a state snapshot every N blocks; 0 disables
+	PruneDepth       int // keep state only for the last N blocks; 0 keeps all
+}
+
+var DefaultChainOptions = ChainOptions{SnapshotInterval: 100}
+
+// ErrStatePruned is returned when asking for state older than PruneDepth.
+var ErrStatePruned = errors.New("state has been pruned")
+
+// ---------------- SNAPSHOT STRUCT ----------------
+// A Snapshot is the full account state after the block at Height. It can
+// be checked against that block's StateRoot without replaying anything.
+type Snapshot struct {
+	Height    int                 `json:"Height"`
+	BlockHash string              `json:"BlockHash"`
+	StateRoot string              `json:"StateRoot"`
+	Accounts  map[string]*Account `json:"Accounts"`
+	Jails     map[string]*Jail    `json:"Jails,omitempty"`
+}
+
+func newSnapshot(block Block, state *State) *Snapshot {
+	state = state.Copy()
+	return &Snapshot{
+		Height:    block.Index,
+		BlockHash: block.Hash,
+		StateRoot: block.StateRoot,
+		Accounts:  state.Accounts,
+		Jails:     state.Jails,
+	}
+}
+
+// State returns the snapshot's accounts and jails as a State.
+func (s *Snapshot) State() *State {
+	return (&State{Accounts: s.Accounts, Jails: s.Jails}).Copy()
+}
+
+// Verify checks the snapshot against the block it claims to belong to.
+func (s *Snapshot) Verify(block Block) error {
+	if block.Index != s.Height || block.Hash != s.BlockHash {
+		return fmt.Errorf("snapshot is for block %d (%s), not block %d (%s)", s.Height, s.BlockHash, block.Index, block.Hash)
+	}
+	if root := s.State().Root(); root != block.StateRoot {
+		return fmt.Errorf("snapshot state root %s does not match header state root %s", root, block.StateRoot)
+	}
+	return nil
+}
+
+// ---------------- SNAPSHOT FILES ----------------
+// Snapshots live in a "snapshots" directory next to the chain file, one
+// file per height.
+
+func (bc *Blockchain) snapshotDir() string {
+	return filepath.Join(filepath.Dir(bc.path), "snapshots")
+}
+
+func (bc *Blockchain) writeSnapshot(snap *Snapshot) error {
+	if bc.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(bc.snapshotDir(), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(bc.snapshotDir(), fmt.Sprintf("state-%d.json", snap.Height)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(snap)
+}
+
+func (bc *Blockchain) readSnapshot(height int) (*Snapshot, error) {
+	file, err := os.Open(filepath.Join(bc.snapshotDir(), fmt.Sprintf("state-%d.json", height)))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var snap Snapshot
+	if err := json.NewDecoder(file).Decode(&snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// snapshotHeights lists the heights of snapshot files on disk, ascending.
+func (bc *Blockchain) snapshotHeights() []int {
+	if bc.path == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(bc.snapshotDir())
+	if err != nil {
+		return nil
+	}
+	var heights []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "state-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		h, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "state-"), ".json"))
+		if err == nil {
+			heights = append(heights, h)
+		}
+	}
+	sort.Ints(heights)
+	return heights
+}
+
+// latestSnapshot returns the newest snapshot at or below height that
+// matches this chain, or nil if there is none.
+func (bc *Blockchain) latestSnapshot(height int) *Snapshot {
+	heights := bc.snapshotHeights()
+	for i := len(heights) - 1; i >= 0; i-- {
+		h := heights[i]
+		if h > height || h >= len(bc.Blocks) {
+			continue
+		}
+		snap, err := bc.readSnapshot(h)
+		if err != nil || snap.Verify(bc.Blocks[h]) != nil {
+			continue
+		}
+		return snap
+	}
+	return nil
+}
+
+// LatestSnapshot returns the newest snapshot on disk, or a snapshot of
+// the current state when none has been written yet.
+func (bc *Blockchain) LatestSnapshot() *Snapshot {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if snap := bc.latestSnapshot(len(bc.Blocks) - 1); snap != nil {
+		return snap
+	}
+	return newSnapshot(bc.Blocks[len(bc.Blocks)-1], bc.state)
+}
+
+// TakeSnapshot writes a snapshot of the current state to disk.
+func (bc *Blockchain) TakeSnapshot() (*Snapshot, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	snap := newSnapshot(bc.Blocks[len(bc.Blocks)-1], bc.state)
+	return snap, bc.writeSnapshot(snap)
+}
+
+// ---------------- PRUNING ----------------
+// prune drops in-memory state and snapshot files that are older than
+// PruneDepth, keeping the newest snapshot below the horizon so the
+// remaining window can still be rebuilt after a restart.
+func (bc *Blockchain) prune() {
+	if bc.opts.PruneDepth <= 0 {
+		return
+	}
+	horizon := len(bc.Blocks) - 1 - bc.opts.PruneDepth
+	for h := range bc.history {
+		if h < horizon {
+			delete(bc.history, h)
+		}
+	}
+
+	keep := -1
+	heights := bc.snapshotHeights()
+	for _, h := range heights {
+		if h <= horizon {
+			keep = h
+		}
+	}
+	for _, h := range heights {
+		if h < keep {
+			if err := os.Remove(filepath.Join(bc.snapshotDir(), fmt.Sprintf("state-%d.json", h))); err != nil {
+				bc.logs.chain.Warn("removing snapshot failed", "height", h, "err", err)
+				continue
+			}
+			bc.logs.chain.Debug("snapshot pruned", "height", h)
+		}
+	}
+}
+
+// ---------------- SNAPSHOT SYNC ----------------
+// ImportSnapshot replaces a fresh chain with blocks fetched from a peer.
+// Block links, hashes and timestamps are checked for the whole chain, and
+// proposers, signatures and block limits up to the snapshot, which is
+// checked against its header's StateRoot. Only blocks after the snapshot
+// are executed, each fully checked like an imported block. All of it
+// happens under bc.mu, and a failed import leaves the chain as it was.
+func (bc *Blockchain) ImportSnapshot(blocks []Block, snap *Snapshot) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if err := verifyLinks(blocks); err != nil {
+		return err
+	}
+	if blocks[0].Hash != bc.Blocks[0].Hash {
+		return fmt.Errorf("%w: peer has %s, we have %s", ErrGenesisMismatch, blocks[0].Hash, bc.Blocks[0].Hash)
+	}
+	if len(bc.Blocks) > 1 {
+		return errors.New("snapshot sync needs a chain with only a genesis block")
+	}
+	if snap.Height < 0 || snap.Height >= len(blocks) {
+		return fmt.Errorf("snapshot height %d is outside the received chain", snap.Height)
+	}
+	if err := snap.Verify(blocks[snap.Height]); err != nil {
+		return err
+	}
+
+	now := bc.clock.Now()
+	for i := 1; i < len(blocks); i++ {
+		if err := bc.checkTimestamp(blocks[:i], blocks[i], now); err != nil {
+			bc.metrics.InvalidBlocks.Inc()
+			return err
+		}
+	}
+	// Before the snapshot there is no state to check proposers against,
+	// only the jails its blocks' evidence imposed.
+	jails := NewState()
+	for _, block := range blocks[1 : snap.Height+1] {
+		if err := bc.checkSignature(block); err != nil {
+			bc.metrics.InvalidBlocks.Inc()
+			return fmt.Errorf("%w: %w", ErrInvalidBlock, err)
+		}
+		if err := bc.checkSlot(jails, block); err != nil {
+			bc.metrics.InvalidBlocks.Inc()
+			return fmt.Errorf("%w: %w", ErrInvalidBlock, err)
+		}
+		for _, e := range block.Evidence {
+			jails.jail(e.Validator(), bc.config.jailFor(e, block))
+		}
+		if err := checkLimits(block, bc.config.BlockLimits()); err != nil {
+			bc.metrics.InvalidBlocks.Inc()
+			return fmt.Errorf("%w: block %d: %w", ErrInvalidBlock, block.Index, err)
+		}
+	}
+	history := map[int]*State{}
+	state := snap.State()
+	history[snap.Height] = state
+	for i := snap.Height + 1; i < len(blocks); i++ {
+		next, err := bc.checkBlock(blocks[i-1], state, blocks[i])
+		if err != nil {
+			return err
+		}
+		state = next
+		history[blocks[i].Index] = state
+	}
+
+	if err := bc.writeSnapshot(snap); err != nil {
+		return err
+	}
+	oldBlocks, oldState, oldHistory, oldIndex := bc.Blocks, bc.state, bc.history, bc.index
+	bc.Blocks, bc.state, bc.history = blocks, state, history
+	if err := bc.rebuildIndex(oldIndex.Kinds); err != nil {
+		// The index file names its head; a stale one is rebuilt on load.
+		bc.Blocks, bc.state, bc.history, bc.index = oldBlocks, oldState, oldHistory, oldIndex
+		return err
+	}
+	bc.metrics.Height.Set(float64(len(blocks) - 1))
+	bc.prune()
+	bc.logs.chain.Info("imported chain from snapshot", "height", len(blocks)-1, "snapshot", snap.Height,
+		"hash", blocks[len(blocks)-1].Hash)
+	return bc.save()
+}
+
+// verifyLinks checks every block hash and its link to the previous block.
+// It does not execute any transactions.
+func verifyLinks(blocks []Block) error {
+	if len(blocks) == 0 {
+		return errors.New("no blocks received")
+	}
+	for i, block := range blocks {
+		if block.Index != i {
+			return fmt.Errorf("block %d has index %d", i, block.Index)
+		}
+		if block.Hash != CalculateHash(block) {
+			return fmt.Errorf("block %d: invalid hash", i)
+		}
+		if block.TxRoot != TxRoot(block.Txs) {
+			return fmt.Errorf("block %d: transaction root mismatch", i)
+		}
+		if i > 0 && block.PrevHash != blocks[i-1].Hash {
+			return fmt.Errorf("block %d: does not link to block %d", i, i-1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestChain returns a chain stored in a temp dir with a funded account
+// and a few transfer blocks.
+func newTestChain(t *testing.T, opts ChainOptions, blocks int) *Blockchain {
+	t.Helper()
+	bc, err := LoadBlockchain(filepath.Join(t.TempDir(), "blocks.json"), fundedSpec(1000, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.SetOptions(opts)
+	for i := 0; i < blocks; i++ {
+		if _, err := bc.CommitBlock("send", []Transaction{transfer("alice", "bob", 1, 0, uint64(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bc
+}
+
+func TestLoadFromSnapshot(t *testing.T) {
+	bc := newTestChain(t, ChainOptions{SnapshotInterval: 4}, 10)
+
+	if got := bc.snapshotHeights(); len(got) != 2 || got[0] != 4 || got[1] != 8 {
+		t.Fatalf("expected snapshots at 4 and 8, got %v", got)
+	}
+
+	loaded, err := LoadBlockchain(bc.path, bc.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.history[7]; ok {
+		t.Fatal("blocks before the snapshot were replayed")
+	}
+	if loaded.State().Root() != bc.State().Root() {
+		t.Fatal("state after loading from snapshot differs")
+	}
+
+	// Older state is rebuilt on demand.
+	proof, err := loaded.GetProof(addr("bob"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAccountProof(loaded.Blocks[3].StateRoot, proof); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruning(t *testing.T) {
+	bc := newTestChain(t, ChainOptions{SnapshotInterval: 2, PruneDepth: 3}, 10)
+
+	if _, err := bc.GetProof(addr("bob"), 2); !errors.Is(err, ErrStatePruned) {
+		t.Fatalf("expected pruned state, got %v", err)
+	}
+	if _, err := bc.GetProof(addr("bob"), 8); err != nil {
+		t.Fatalf("recent state should be available: %v", err)
+	}
+	// Horizon is 10-3 = 7, so the newest snapshot at or below it (6) is kept.
+	if got := bc.snapshotHeights(); got[0] != 6 {
+		t.Fatalf("expected oldest kept snapshot at 6, got %v", got)
+	}
+}
+
+func TestStateAtReportsBadBlocks(t *testing.T) {
+	bc := newTestChain(t, ChainOptions{}, 4)
+	bc.Blocks[2].Txs[0].Amount = 500
+	delete(bc.history, 2)
+	delete(bc.history, 3)
+
+	if _, err := bc.GetProof(addr("bob"), 3); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expected the replay to fail, got %v", err)
+	}
+}
+
+func TestSnapshotSync(t *testing.T) {
+	source := newTestChain(t, ChainOptions{SnapshotInterval: 5}, 8)
+	server := NewNetwork("127.0.0.1:0", source, NewMempool(source, DefaultMempoolConfig), nil)
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	fresh, err := LoadBlockchain(filepath.Join(t.TempDir(), "blocks.json"), source.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewNetwork("127.0.0.1:0", fresh, NewMempool(fresh, DefaultMempoolConfig), nil)
+	if err := client.SnapshotSync(server.ln.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	if fresh.Height() != source.Height() || fresh.State().Root() != source.State().Root() {
+		t.Fatal("synced chain does not match source")
+	}
+	if _, ok := fresh.history[4]; ok {
+		t.Fatal("blocks before the snapshot were executed")
+	}
+}
+
+func TestImportSnapshotRejectsBadState(t *testing.T) {
+	source := newTestChain(t, ChainOptions{}, 4)
+	snap := source.LatestSnapshot()
+	snap.Accounts["mallory"] = &Account{Balance: 1000000}
+
+	fresh := NewBlockchainWithConfig(source.Config())
+	if err := fresh.ImportSnapshot(source.BlocksFrom(0), snap); err == nil {
+		t.Fatal("tampered snapshot was accepted")
+	}
+	if fresh.Height() != 0 {
+		t.Fatal("chain changed after a rejected import")
+	}
+}
+
+func TestImportSnapshotFailureKeepsChain(t *testing.T) {
+	source := newTestChain(t, ChainOptions{}, 4)
+	fresh, err := LoadBlockchain(filepath.Join(t.TempDir(), "blocks.json"), source.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory where the index file goes makes saving the index fail.
+	if err := os.Remove(fresh.indexPath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(fresh.indexPath(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fresh.ImportSnapshot(source.BlocksFrom(0), source.LatestSnapshot()); err == nil {
+		t.Fatal("import succeeded without an index")
+	}
+	if fresh.Height() != 0 || fresh.State().Root() != fresh.Head().StateRoot {
+		t.Fatal("chain changed after a failed import")
+	}
+	if _, err := fresh.BlockByHash(source.Head().Hash); err == nil {
+		t.Fatal("index kept a block of the failed import")
+	}
+
+	os.Remove(fresh.indexPath())
+	if err := fresh.ImportSnapshot(source.BlocksFrom(0), source.LatestSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportSnapshotChecksBlocks(t *testing.T) {
+	clock := NewSimClock(simStart)
+	source := proposeAs(NewBlockchainWithConfig(poaSpec()), "v1")
+	source.SetClock(clock)
+	var snap *Snapshot
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			snap = source.LatestSnapshot()
+		}
+		if _, err := source.CommitBlock("signed", nil); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(5 * time.Second)
+	}
+
+	// An unsigned block is refused before the snapshot, where nothing is
+	// executed, and after it.
+	for _, height := range []int{1, 3} {
+		blocks := source.BlocksFrom(0)
+		blocks[height].Signature = ""
+		fresh := NewBlockchainWithConfig(poaSpec())
+		fresh.SetClock(clock)
+		if err := fresh.ImportSnapshot(blocks, snap); !errors.Is(err, ErrBlockSignature) {
+			t.Fatalf("block %d: expected ErrBlockSignature, got %v", height, err)
+		}
+		if fresh.Height() != 0 {
+			t.Fatal("chain changed after a rejected import")
+		}
+	}
+	fresh := NewBlockchainWithConfig(poaSpec())
+	fresh.SetClock(clock)
+	if err := fresh.ImportSnapshot(source.BlocksFrom(0), snap); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,167 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ---------------- ACCOUNT ----------------
+type Account struct {
+	Balance int    `json:"Balance"`
+	Nonce   uint64 `json:"Nonce"`
+}
+
+// encode is the value stored in the state tree for an account.
+func (a Account) encode() []byte {
+	return []byte(fmt.Sprintf("%d|%d", a.Balance, a.Nonce))
+}
+
+// ---------------- STATE ----------------
+// State is the account state after some block. Each block header commits
+// to it through StateRoot.
+type State struct {
+	Accounts map[string]*Account `json:"Accounts"`
+	Jails    map[string]*Jail    `json:"Jails,omitempty"` // validator address -> last punishment
+}
+
+func NewState() *State {
+	return &State{Accounts: make(map[string]*Account)}
+}
+
+// Get returns the account at address, or nil if it has never been touched.
+func (s *State) Get(address string) *Account {
+	return s.Accounts[address]
+}
+
+func (s *State) Balance(address string) int {
+	if acc := s.Get(address); acc != nil {
+		return acc.Balance
+	}
+	return 0
+}
+
+func (s *State) account(address string) *Account {
+	acc := s.Accounts[address]
+	if acc == nil {
+		acc = &Account{}
+		s.Accounts[address] = acc
+	}
+	return acc
+}
+
+func (s *State) Copy() *State {
+	out := NewState()
+	for addr, acc := range s.Accounts {
+		a := *acc
+		out.Accounts[addr] = &a
+	}
+	for addr, j := range s.Jails {
+		c := *j
+		out.jail(addr, &c)
+	}
+	return out
+}
+
+// Addresses returns every account address in sorted order.
+func (s *State) Addresses() []string {
+	out := make([]string, 0, len(s.Accounts))
+	for addr := range s.Accounts {
+		out = append(out, addr)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ---------------- STATE TRANSITION ----------------
+// applyRules are checked by ApplyTx itself. The remaining stateless rules
+// (signature, size, chain parameters) are the caller's job.
+var applyRules = append([]Rule{RuleSyntax, RuleAmount, RuleFee}, StateRules...)
+
+// ApplyTx moves funds for one transaction. Its nonce must be exactly the
+// sender's next nonce, which rules out replays. The sender pays Amount + Fee;
+// the fee is handed out by the end-of-block hooks. Every coin has a sender:
+// new ones only come from the genesis alloc.
+func (s *State) ApplyTx(tx Transaction) error {
+	if err := ValidateTx(tx, &RuleContext{State: s}, applyRules); err != nil {
+		return err
+	}
+	from := s.account(tx.From)
+	from.Balance -= tx.Amount + tx.Fee
+	from.Nonce++
+	s.account(tx.To).Balance += tx.Amount
+	return nil
+}
+
+// ---------------- END-OF-BLOCK HOOKS ----------------
+// An EndBlockHook runs after all transactions of a block have been applied
+// and may change the state further. Hooks run in order and their changes
+// are part of the block's StateRoot.
+type EndBlockHook func(state *State, block Block) error
+
+// CreditFees pays the fees of every transaction in the block to the
+// block's proposer. Blocks without a proposer burn their fees.
+func CreditFees(state *State, block Block) error {
+	if block.Proposer == "" {
+		return nil
+	}
+	total := 0
+	for _, tx := range block.Txs {
+		total += tx.Fee
+	}
+	if total > 0 {
+		state.account(block.Proposer).Balance += total
+	}
+	return nil
+}
+
+// ---------------- STATE ROOT ----------------
+func (s *State) tree() *SparseMerkleTree {
+	t := NewSparseMerkleTree()
+	for addr, acc := range s.Accounts {
+		t.Update(stateKey(addr), acc.encode())
+	}
+	for addr, j := range s.Jails {
+		t.Update(jailKey(addr), j.encode())
+	}
+	return t
+}
+
+func (s *State) Root() string {
+	return hex.EncodeToString(s.tree().Root())
+}
+
+func stateKey(address string) [32]byte {
+	return sha256.Sum256([]byte(address))
+}
+
+// ---------------- ACCOUNT PROOF ----------------
+// AccountProof shows an account's balance and nonce at a block, or that the
+// account does not exist there (Account is nil).
+type AccountProof struct {
+	Address   string      `json:"Address"`
+	Height    int         `json:"Height"`
+	StateRoot string      `json:"StateRoot"`
+	Account   *Account    `json:"Account"`
+	Proof     MerkleProof `json:"Proof"`
+}
+
+func (s *State) Prove(address string) MerkleProof {
+	return s.tree().Prove(stateKey(address))
+}
+
+// VerifyAccountProof checks p against a state root the caller already
+// trusts, normally copied from a block header. It needs no chain data.
+func VerifyAccountProof(stateRoot string, p *AccountProof) error {
+	root, err := hex.DecodeString(stateRoot)
+	if err != nil {
+		return errors.New("malformed state root")
+	}
+	var value []byte
+	if p.Account != nil {
+		value = p.Account.encode()
+	}
+	return VerifyMerkleProof(root, stateKey(p.Address), value, p.Proof)
+}
@@ -0,0 +1,185 @@
+package node
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+// testWallet derives a fixed key pair from name, so tests can refer to
+// accounts by name.
+func testWallet(name string) *Wallet {
+	seed := sha256.Sum256([]byte(name))
+	return WalletFromSeed(seed[:])
+}
+
+func addr(name string) string {
+	return testWallet(name).Address
+}
+
+// fundedSpec is the default chain with balance allocated at genesis to
+// each of the named accounts.
+func fundedSpec(balance int, names ...string) *Config {
+	cfg := DefaultConfig()
+	for _, name := range names {
+		cfg.Alloc = append(cfg.Alloc, GenesisAlloc{Address: addr(name), Balance: balance})
+		cfg.InitialSupply += balance
+	}
+	return cfg
+}
+
+// transfer returns a transfer signed by from for the default chain.
+func transfer(from, to string, amount, fee int, nonce uint64) Transaction {
+	tx := Transaction{To: addr(to), Amount: amount, Fee: fee, Nonce: nonce}
+	testWallet(from).SignTx(&tx, DefaultConfig().ChainID)
+	return tx
+}
+
+func TestStateRootInHeader(t *testing.T) {
+	bc := NewBlockchainWithConfig(fundedSpec(100, "alice"))
+
+	if _, err := bc.CommitBlock("send", []Transaction{transfer("alice", "bob", 40, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	head := bc.Blocks[len(bc.Blocks)-1]
+	if head.StateRoot != bc.State().Root() {
+		t.Fatal("header state root does not match chain state")
+	}
+	if bc.Blocks[0].StateRoot == head.StateRoot {
+		t.Fatal("state root did not change after a transfer")
+	}
+}
+
+func TestNoMintsAfterGenesis(t *testing.T) {
+	bc := NewBlockchain()
+	mint := Transaction{To: addr("alice"), Amount: 10}
+	if err := NewState().ApplyTx(mint); !errors.Is(err, ErrMalformedTx) {
+		t.Fatalf("state applied a mint: %v", err)
+	}
+	if _, err := bc.CommitBlock("mint", []Transaction{mint}); !errors.Is(err, ErrMalformedTx) {
+		t.Fatalf("committed a mint: %v", err)
+	}
+	if err := NewMempool(bc, DefaultMempoolConfig).Add(mint); !errors.Is(err, ErrNotPoolable) {
+		t.Fatalf("mempool took a mint: %v", err)
+	}
+
+	// A peer's block with a mint in it is refused.
+	block, _ := bc.CommitBlock("empty", nil)
+	block.Txs = []Transaction{mint}
+	block.TxRoot = TxRoot(block.Txs)
+	block.Hash = CalculateHash(block)
+	if err := NewBlockchain().ImportBlock(block); !errors.Is(err, ErrInvalidBlock) || !errors.Is(err, ErrMalformedTx) {
+		t.Fatalf("imported a block with a mint: %v", err)
+	}
+}
+
+func TestAccountProof(t *testing.T) {
+	bc := NewBlockchainWithConfig(fundedSpec(100, "alice"))
+	bc.CommitBlock("send", []Transaction{transfer("alice", "bob", 40, 0, 0)})
+
+	// Inclusion at the head.
+	proof, err := bc.GetProof(addr("alice"), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Account == nil || proof.Account.Balance != 60 || proof.Account.Nonce != 1 {
+		t.Fatalf("unexpected account in proof: %+v", proof.Account)
+	}
+	if err := VerifyAccountProof(bc.Blocks[1].StateRoot, proof); err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+
+	// Non-inclusion: bob did not exist at genesis.
+	proof, err = bc.GetProof(addr("bob"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Account != nil {
+		t.Fatal("expected bob to be absent at genesis")
+	}
+	if err := VerifyAccountProof(bc.Blocks[0].StateRoot, proof); err != nil {
+		t.Fatalf("valid non-inclusion proof rejected: %v", err)
+	}
+
+	// A forged balance must not verify.
+	proof, _ = bc.GetProof(addr("alice"), -1)
+	proof.Account.Balance = 1000
+	if err := VerifyAccountProof(bc.Blocks[1].StateRoot, proof); err == nil {
+		t.Fatal("forged balance verified")
+	}
+
+	// Neither must a proof checked against another block's root.
+	proof, _ = bc.GetProof(addr("alice"), -1)
+	if err := VerifyAccountProof(bc.Blocks[0].StateRoot, proof); err == nil {
+		t.Fatal("proof verified against the wrong root")
+	}
+}
+
+func TestFeesCreditedToProposer(t *testing.T) {
+	cfg := fundedSpec(100, "alice")
+	cfg.MinTxFee = 2
+	bc := NewBlockchainWithConfig(cfg)
+	bc.SetProposer(addr("producer"))
+
+	txs := []Transaction{
+		transfer("alice", "bob", 10, 2, 0),
+		transfer("alice", "bob", 10, 3, 1),
+	}
+	block, err := bc.CommitBlock("send", txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Proposer != addr("producer") {
+		t.Fatalf("expected proposer in header, got %q", block.Proposer)
+	}
+	state := bc.State()
+	if state.Balance(addr("alice")) != 75 || state.Balance(addr("bob")) != 20 || state.Balance(addr("producer")) != 5 {
+		t.Fatalf("unexpected balances: alice=%d bob=%d producer=%d",
+			state.Balance(addr("alice")), state.Balance(addr("bob")), state.Balance(addr("producer")))
+	}
+
+	if _, err := bc.CommitBlock("cheap", []Transaction{transfer("alice", "bob", 1, 1, 2)}); err == nil {
+		t.Fatal("block with a fee below the minimum was accepted")
+	}
+}
+
+func TestReplayProtection(t *testing.T) {
+	bc := NewBlockchainWithConfig(fundedSpec(100, "alice"))
+
+	tx := transfer("alice", "bob", 10, 0, 0)
+	if _, err := bc.CommitBlock("send", []Transaction{tx}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same signed transaction cannot be used twice.
+	if _, err := bc.CommitBlock("replay", []Transaction{tx}); !errors.Is(err, ErrNonceTooLow) {
+		t.Fatalf("expected stale nonce, got %v", err)
+	}
+	// Nor can a sender skip ahead.
+	if _, err := bc.CommitBlock("skip", []Transaction{transfer("alice", "bob", 10, 0, 5)}); !errors.Is(err, ErrNonceTooHigh) {
+		t.Fatalf("expected nonce too high, got %v", err)
+	}
+
+	// A transaction signed for another chain is rejected here.
+	other := Transaction{To: addr("bob"), Amount: 10, Nonce: 1}
+	testWallet("alice").SignTx(&other, "some-other-chain")
+	if _, err := bc.CommitBlock("foreign", []Transaction{other}); !errors.Is(err, ErrWrongChain) {
+		t.Fatalf("expected wrong chain, got %v", err)
+	}
+
+	// Changing any signed field breaks the signature.
+	forged := transfer("alice", "bob", 10, 0, 1)
+	forged.Amount = 90
+	if _, err := bc.CommitBlock("forged", []Transaction{forged}); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expected bad signature, got %v", err)
+	}
+
+	mp := NewMempool(bc, DefaultMempoolConfig)
+	if err := mp.Add(other); !errors.Is(err, ErrWrongChain) {
+		t.Fatalf("mempool accepted a foreign transaction: %v", err)
+	}
+	if err := mp.Add(forged); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("mempool accepted a forged transaction: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ---------------- BLOCK TIME ----------------
+// BlockTime is the timestamp in a block header, to the second. It is
+// written as RFC 3339, the form the block hash covers.
+type BlockTime struct {
+	time.Time
+}
+
+// NewBlockTime returns t as a block timestamp.
+func NewBlockTime(t time.Time) BlockTime {
+	return BlockTime{t.Truncate(time.Second)}
+}
+
+func (t BlockTime) String() string {
+	return t.Format(time.RFC3339)
+}
+
+func (t BlockTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *BlockTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("block timestamp %s: want an RFC 3339 string", data)
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("block timestamp: %w", err)
+	}
+	// The hash covers whole seconds only, so a fraction could change
+	// without changing the hash.
+	if parsed.Nanosecond() != 0 {
+		return fmt.Errorf("block timestamp %s: not a whole second", s)
+	}
+	*t = BlockTime{parsed}
+	return nil
+}
+
+// ---------------- TIMESTAMP RULES ----------------
+// A block's timestamp must be later than the median timestamp of the
+// MedianTimeBlocks blocks before it, so no single proposer can drag the
+// chain's time back, and at most MaxTimeDrift ahead of our clock. When the
+// genesis names PoA validators, it must also fall on a slot boundary:
+// a whole number of slots after the genesis timestamp.
+
+// MedianTimeBlocks is how many blocks the median time is taken over.
+const MedianTimeBlocks = 11
+
+// MaxTimeDrift is how far in the future a block timestamp may be.
+const MaxTimeDrift = 2 * time.Minute
+
+// TimestampError says which timestamp rule a block broke. Match the rule
+// with errors.Is on ErrTimestampTooOld, ErrTimestampFuture or
+// ErrTimestampSlot.
+type TimestampError struct {
+	Height int
+	Time   time.Time
+	Bound  time.Time // the median, the latest allowed time or the nearest slot
+	Err    error
+}
+
+func (e *TimestampError) Error() string {
+	return fmt.Sprintf("block %d timestamp %s: %v (bound %s)", e.Height, e.Time.Format(time.RFC3339), e.Err, e.Bound.Format(time.RFC3339))
+}
+
+func (e *TimestampError) Unwrap() error {
+	return e.Err
+}
+
+// medianTime is the median timestamp of the last MedianTimeBlocks of
+// recent, which ends with the parent of the next block.
+func medianTime(recent []Block) time.Time {
+	if len(recent) > MedianTimeBlocks {
+		recent = recent[len(recent)-MedianTimeBlocks:]
+	}
+	times := make([]time.Time, len(recent))
+	for i, b := range recent {
+		times[i] = b.Timestamp.Time
+	}
+	sort.Slice(times, func(a, b int) bool { return times[a].Before(times[b]) })
+	return times[len(times)/2]
+}
+
+// slotDuration is the PoA slot length, or 0 when blocks need not be
+// aligned to slots.
+func (c *Config) slotDuration() time.Duration {
+	if len(c.Validators) == 0 || c.Consensus.SlotDurationSec <= 0 {
+		return 0
+	}
+	return time.Duration(c.Consensus.SlotDurationSec) * time.Second
+}
+
+// checkTimestamp applies the timestamp rules to block, whose ancestors
+// end with recent, at local time now.
+func (bc *Blockchain) checkTimestamp(recent []Block, block Block, now time.Time) error {
+	t := block.Timestamp.Time
+	fail := func(bound time.Time, err error) error {
+		return &TimestampError{Height: block.Index, Time: t, Bound: bound, Err: err}
+	}
+	if median := medianTime(recent); !t.After(median) {
+		return fail(median, ErrTimestampTooOld)
+	}
+	if limit := now.Add(MaxTimeDrift); t.After(limit) {
+		return fail(limit, ErrTimestampFuture)
+	}
+	if slot := bc.config.slotDuration(); slot > 0 {
+		since := t.Sub(bc.config.Timestamp)
+		if since%slot != 0 {
+			return fail(bc.config.Timestamp.Add(since.Round(slot)), ErrTimestampSlot)
+		}
+	}
+	return nil
+}
+
+// nextTimestamp is the timestamp for a block made now on top of recent:
+// now, or the first second after the median time if now is not later,
+// moved up to the next slot boundary under PoA, and on to the next slot
+// of our proposer if it has one in the coming round. Blocks made faster
+// than that push the time ahead of the clock; past MaxTimeDrift other
+// nodes would refuse the block, so it fails with ErrTimestampFuture
+// instead. The caller holds bc.mu.
+func (bc *Blockchain) nextTimestamp(recent []Block, now time.Time) (BlockTime, error) {
+	t := now.Truncate(time.Second)
+	if min := medianTime(recent).Add(time.Second); t.Before(min) {
+		t = min
+	}
+	if slot := bc.config.slotDuration(); slot > 0 {
+		genesis := bc.config.Timestamp.In(t.Location())
+		if since := t.Sub(genesis); since%slot != 0 {
+			t = genesis.Add(since - since%slot + slot)
+		}
+		for i, s := 0, t; i < len(bc.config.Validators); i, s = i+1, s.Add(slot) {
+			if bc.proposerAt(bc.state, s) == bc.proposer {
+				t = s
+				break
+			}
+		}
+	}
+	if limit := now.Add(MaxTimeDrift); t.After(limit) {
+		height := recent[len(recent)-1].Index + 1
+		return BlockTime{}, &TimestampError{Height: height, Time: t, Bound: limit, Err: ErrTimestampFuture}
+	}
+	return BlockTime{t}, nil
+}
+
+// recentBlocks returns up to MedianTimeBlocks blocks ending with the
+// block at height. The caller holds bc.mu.
+func (bc *Blockchain) recentBlocks(height int) []Block {
+	from := height + 1 - MedianTimeBlocks
+	if from < 0 {
+		from = 0
+	}
+	return bc.Blocks[from : height+1]
+}
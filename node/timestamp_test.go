@@ -0,0 +1,144 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// timedPair returns a chain with one block made at simStart and a second
+// chain, on the same clock, that has imported nothing yet. The first
+// proposes as the validator of testSpec.
+func timedPair(t *testing.T, cfg *Config) (*Blockchain, *Blockchain, *SimClock) {
+	t.Helper()
+	clock := NewSimClock(simStart)
+	a, b := proposeAs(NewBlockchainWithConfig(cfg), "validator"), NewBlockchainWithConfig(cfg)
+	a.SetClock(clock)
+	b.SetClock(clock)
+	if _, err := a.CommitBlock("one", nil); err != nil {
+		t.Fatal(err)
+	}
+	return a, b, clock
+}
+
+// retimed returns block with timestamp t and a hash to match.
+func retimed(block Block, t time.Time) Block {
+	block.Timestamp = NewBlockTime(t)
+	block.Hash = CalculateHash(block)
+	return block
+}
+
+func TestTimestampRules(t *testing.T) {
+	a, b, _ := timedPair(t, DefaultConfig())
+	block := a.Blocks[1]
+
+	var tsErr *TimestampError
+	err := b.ImportBlock(retimed(block, b.Blocks[0].Timestamp.Time))
+	if !errors.Is(err, ErrTimestampTooOld) || !errors.As(err, &tsErr) || tsErr.Height != 1 {
+		t.Fatalf("expected a too old timestamp, got %v", err)
+	}
+	err = b.ImportBlock(retimed(block, simStart.Add(MaxTimeDrift+time.Second)))
+	if !errors.Is(err, ErrTimestampFuture) {
+		t.Fatalf("expected a future timestamp, got %v", err)
+	}
+	if err := b.ImportBlock(retimed(block, simStart.Add(MaxTimeDrift))); err != nil {
+		t.Fatalf("timestamp at the drift limit refused: %v", err)
+	}
+}
+
+func TestTimestampSlots(t *testing.T) {
+	a, b, clock := timedPair(t, testSpec())
+	slot := time.Duration(testSpec().Consensus.SlotDurationSec) * time.Second
+
+	clock.Advance(1500 * time.Millisecond)
+	next, err := a.CommitBlock("two", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if since := next.Timestamp.Sub(a.Config().Timestamp); since%slot != 0 || !next.Timestamp.After(a.Blocks[1].Timestamp.Time) {
+		t.Fatalf("block made at %s is not on a later slot", next.Timestamp)
+	}
+	if err := b.ImportBlock(a.Blocks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ImportBlock(retimed(next, next.Timestamp.Add(-time.Second))); !errors.Is(err, ErrTimestampSlot) {
+		t.Fatalf("expected a misaligned timestamp, got %v", err)
+	}
+	if err := b.ImportBlock(next); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotSyncChecksTimestamps(t *testing.T) {
+	a, b, _ := timedPair(t, DefaultConfig())
+	for _, c := range []struct {
+		at   time.Time
+		want error
+	}{
+		{b.Blocks[0].Timestamp.Time, ErrTimestampTooOld},
+		{simStart.Add(MaxTimeDrift + time.Second), ErrTimestampFuture},
+	} {
+		// The snapshot is of the bad block itself, so nothing is replayed.
+		block := retimed(a.Blocks[1], c.at)
+		snap := newSnapshot(block, a.State())
+		if err := b.ImportSnapshot([]Block{a.Blocks[0], block}, snap); !errors.Is(err, c.want) {
+			t.Fatalf("expected %v, got %v", c.want, err)
+		}
+	}
+	if err := b.ImportSnapshot(a.BlocksFrom(0), a.LatestSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFastProductionStopsAtDrift(t *testing.T) {
+	clock := NewSimClock(simStart)
+	bc := proposeAs(NewBlockchainWithConfig(testSpec()), "validator")
+	bc.SetClock(clock)
+	var err error
+	for made := 0; err == nil; made++ {
+		if made > 1000 {
+			t.Fatal("blocks kept coming without the clock moving")
+		}
+		_, err = bc.CommitBlock("fast", nil)
+	}
+	var tsErr *TimestampError
+	if !errors.Is(err, ErrTimestampFuture) || !errors.As(err, &tsErr) || tsErr.Height != bc.Height()+1 {
+		t.Fatalf("expected a future timestamp, got %v", err)
+	}
+	if bc.Head().Timestamp.After(simStart.Add(MaxTimeDrift)) {
+		t.Fatalf("made a block at %s", bc.Head().Timestamp)
+	}
+
+	// Another node on the same clock takes every block made.
+	other := NewBlockchainWithConfig(testSpec())
+	other.SetClock(clock)
+	for _, block := range bc.BlocksFrom(1) {
+		if err := other.ImportBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Advance(time.Minute)
+	if _, err := bc.CommitBlock("later", nil); err != nil {
+		t.Fatalf("still refused once the clock moved: %v", err)
+	}
+}
+
+func TestBlockTimeJSON(t *testing.T) {
+	var b Block
+	if err := json.Unmarshal([]byte(`{"Index":1,"Timestamp":"2030-01-01T12:00:00Z"}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	if !b.Timestamp.Equal(simStart) {
+		t.Fatalf("read as %s", b.Timestamp)
+	}
+	data, _ := json.Marshal(b.Timestamp)
+	if string(data) != `"2030-01-01T12:00:00Z"` {
+		t.Fatalf("written as %s", data)
+	}
+	for _, bad := range []string{`"yesterday"`, `1893499200`, `"2030-01-01T12:00:00.5Z"`} {
+		if err := json.Unmarshal([]byte(`{"Timestamp":`+bad+`}`), &b); err == nil {
+			t.Fatalf("accepted the timestamp %s", bad)
+		}
+	}
+}
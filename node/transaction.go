@@ -0,0 +1,93 @@
+package node
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ---------------- TRANSACTION STRUCT ----------------
+// A transfer is signed by its sender over the chain ID and the nonce as
+// well as the amounts, so it can be used exactly once and on one chain
+// only. Mint transactions have no sender and carry no signature.
+type Transaction struct {
+	ChainID   string `json:"ChainID,omitempty"`
+	From      string `json:"From"`
+	To        string `json:"To"`
+	Amount    int    `json:"Amount"`
+	Fee       int    `json:"Fee"`
+	Nonce     uint64 `json:"Nonce"`
+	PubKey    string `json:"PubKey,omitempty"`
+	Signature string `json:"Signature,omitempty"`
+}
+
+// SigningPayload is the exact byte string the sender signs.
+func (tx Transaction) SigningPayload() []byte {
+	return []byte(fmt.Sprintf("%s|%s|%s|%d|%d|%d", tx.ChainID, tx.From, tx.To, tx.Amount, tx.Fee, tx.Nonce))
+}
+
+// ---------------- TRANSACTION HASH ----------------
+func (tx Transaction) Hash() string {
+	record := fmt.Sprintf("%s|%s|%s", tx.SigningPayload(), tx.PubKey, tx.Signature)
+	h := sha256.Sum256([]byte(record))
+	return hex.EncodeToString(h[:])
+}
+
+// ---------------- VERIFY TRANSACTION ----------------
+// Verify checks that tx is meant for chainID and is signed by the key
+// behind its From address.
+func (tx Transaction) Verify(chainID string) error {
+	if tx.From == "" {
+		return fmt.Errorf("%w: no sender", ErrBadSignature)
+	}
+	if tx.ChainID != chainID {
+		return fmt.Errorf("%w: signed for %q, this is %q", ErrWrongChain, tx.ChainID, chainID)
+	}
+	pub, err := hex.DecodeString(tx.PubKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: malformed public key", ErrBadSignature)
+	}
+	if AddressFromPublicKey(pub) != tx.From {
+		return fmt.Errorf("%w: public key does not match sender", ErrBadSignature)
+	}
+	sig, err := hex.DecodeString(tx.Signature)
+	if err != nil || !ed25519.Verify(pub, tx.SigningPayload(), sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Size is the encoded size of the transaction in bytes. Block size limits
+// and fee rates are measured with it.
+func (tx Transaction) Size() int {
+	b, _ := json.Marshal(tx)
+	return len(b)
+}
+
+// ---------------- TRANSACTION ROOT ----------------
+// TxRoot is a plain binary Merkle root over the transaction hashes of a
+// block, duplicating the last hash on odd levels.
+func TxRoot(txs []Transaction) string {
+	if len(txs) == 0 {
+		return ""
+	}
+	level := make([][]byte, len(txs))
+	for i, tx := range txs {
+		b, _ := hex.DecodeString(tx.Hash())
+		level[i] = b
+	}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([][]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			h := sha256.Sum256(append(append([]byte{}, level[i]...), level[i+1]...))
+			next = append(next, h[:])
+		}
+		level = next
+	}
+	return hex.EncodeToString(level[0])
+}
@@ -0,0 +1,179 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// Transactions are checked by a list of small rules. Each rule looks at
+// one property and fails with one of the errors in errors.go. The same
+// rules run wherever a transaction enters the node: the REPL, RPC, gossip
+// and block import.
+
+// MaxTxBytes is the largest encoded transaction accepted when the genesis
+// spec sets no limit.
+const MaxTxBytes = 2048
+
+// ---------------- RULE CONTEXT ----------------
+// RuleContext is everything a rule may look at besides the transaction.
+type RuleContext struct {
+	ChainID           string
+	MinTxFee          int
+	MaxTxBytes        int // 0 means no limit
+	MaxAmount         int // 0 means no limit
+	AllowSelfTransfer bool
+	MaxNonceGap       uint64 // how far ahead of the account a nonce may be
+	State             *State // nil skips the state rules
+}
+
+// RuleContext returns the rule context for this chain's parameters and
+// current state.
+func (bc *Blockchain) RuleContext() *RuleContext {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.ruleContext(bc.state)
+}
+
+// ruleContext is RuleContext for a given state. The caller holds bc.mu.
+func (bc *Blockchain) ruleContext(state *State) *RuleContext {
+	return &RuleContext{
+		ChainID:           bc.config.ChainID,
+		MinTxFee:          bc.config.MinTxFee,
+		MaxTxBytes:        bc.config.BlockLimits().MaxTxBytes,
+		MaxAmount:         bc.config.InitialSupply,
+		AllowSelfTransfer: bc.config.AllowSelfTransfer,
+		State:             state,
+	}
+}
+
+// ---------------- RULES ----------------
+type Rule struct {
+	Name  string
+	Check func(tx Transaction, ctx *RuleContext) error
+}
+
+// StatelessRules need only the transaction and the chain parameters.
+var StatelessRules = []Rule{
+	RuleSyntax, RuleSelfTransfer, RuleSize, RuleAmount, RuleFee, RuleOverflow, RuleSignature,
+}
+
+// StateRules check the transaction against account state.
+var StateRules = []Rule{RuleOverflow, RuleNonce, RuleBalance}
+
+// DefaultRules is the full pipeline.
+var DefaultRules = append(append([]Rule{}, StatelessRules...), RuleNonce, RuleBalance)
+
+// ValidateTx runs rules in order and returns the first failure as a
+// *TxError.
+func ValidateTx(tx Transaction, ctx *RuleContext, rules []Rule) error {
+	for _, r := range rules {
+		if err := r.Check(tx, ctx); err != nil {
+			return &TxError{Hash: tx.Hash(), Rule: r.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+var RuleSyntax = Rule{"syntax", func(tx Transaction, ctx *RuleContext) error {
+	if !isAddress(tx.To) {
+		return fmt.Errorf("%w: bad receiver address %q", ErrMalformedTx, tx.To)
+	}
+	if tx.From == "" {
+		return fmt.Errorf("%w: no sender; coins are only created by the genesis alloc", ErrMalformedTx)
+	}
+	if !isAddress(tx.From) {
+		return fmt.Errorf("%w: bad sender address %q", ErrMalformedTx, tx.From)
+	}
+	return nil
+}}
+
+var RuleSelfTransfer = Rule{"self-transfer", func(tx Transaction, ctx *RuleContext) error {
+	if tx.From == tx.To && !ctx.AllowSelfTransfer {
+		return ErrSelfTransfer
+	}
+	return nil
+}}
+
+var RuleSize = Rule{"size", func(tx Transaction, ctx *RuleContext) error {
+	if ctx.MaxTxBytes > 0 && tx.Size() > ctx.MaxTxBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrTxTooLarge, tx.Size(), ctx.MaxTxBytes)
+	}
+	return nil
+}}
+
+var RuleAmount = Rule{"amount", func(tx Transaction, ctx *RuleContext) error {
+	if tx.Amount <= 0 {
+		return fmt.Errorf("%w: %d is not positive", ErrInvalidAmount, tx.Amount)
+	}
+	if ctx.MaxAmount > 0 && tx.Amount > ctx.MaxAmount {
+		return fmt.Errorf("%w: %d exceeds the total supply %d", ErrInvalidAmount, tx.Amount, ctx.MaxAmount)
+	}
+	return nil
+}}
+
+var RuleFee = Rule{"fee", func(tx Transaction, ctx *RuleContext) error {
+	if tx.Fee < 0 {
+		return fmt.Errorf("%w: %d is negative", ErrFeeTooLow, tx.Fee)
+	}
+	if tx.Fee < ctx.MinTxFee {
+		return fmt.Errorf("%w: %d, minimum %d", ErrFeeTooLow, tx.Fee, ctx.MinTxFee)
+	}
+	return nil
+}}
+
+var RuleOverflow = Rule{"overflow", func(tx Transaction, ctx *RuleContext) error {
+	if tx.Amount > math.MaxInt-tx.Fee {
+		return fmt.Errorf("%w: amount plus fee", ErrOverflow)
+	}
+	if ctx.State != nil && ctx.State.Balance(tx.To) > math.MaxInt-tx.Amount {
+		return fmt.Errorf("%w: receiver balance", ErrOverflow)
+	}
+	return nil
+}}
+
+var RuleSignature = Rule{"signature", func(tx Transaction, ctx *RuleContext) error {
+	return tx.Verify(ctx.ChainID)
+}}
+
+var RuleNonce = Rule{"nonce", func(tx Transaction, ctx *RuleContext) error {
+	if ctx.State == nil {
+		return nil
+	}
+	var next uint64
+	if acc := ctx.State.Get(tx.From); acc != nil {
+		next = acc.Nonce
+	}
+	if tx.Nonce < next {
+		return fmt.Errorf("%w: got %d, account is at %d", ErrNonceTooLow, tx.Nonce, next)
+	}
+	if tx.Nonce-next > ctx.MaxNonceGap {
+		return fmt.Errorf("%w: got %d, account is at %d", ErrNonceTooHigh, tx.Nonce, next)
+	}
+	return nil
+}}
+
+var RuleBalance = Rule{"balance", func(tx Transaction, ctx *RuleContext) error {
+	if ctx.State == nil {
+		return nil
+	}
+	if have := ctx.State.Balance(tx.From); have < tx.Amount+tx.Fee {
+		return fmt.Errorf("%w: has %d, needs %d", ErrInsufficientBalance, have, tx.Amount+tx.Fee)
+	}
+	return nil
+}}
+
+// isAddress reports whether s looks like a wallet address: 16 bytes in
+// lowercase hex.
+func isAddress(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && s == toLowerHex(s)
+}
+
+func toLowerHex(s string) string {
+	b, _ := hex.DecodeString(s)
+	return hex.EncodeToString(b)
+}
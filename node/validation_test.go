@@ -0,0 +1,83 @@
+package node
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationRules(t *testing.T) {
+	state := NewState()
+	state.account(addr("alice")).Balance = 100
+	ctx := &RuleContext{
+		ChainID:    DefaultConfig().ChainID,
+		MinTxFee:   1,
+		MaxTxBytes: MaxTxBytes,
+		MaxAmount:  1000,
+		State:      state,
+	}
+
+	selfTx := Transaction{To: addr("alice"), Amount: 10, Fee: 1}
+	testWallet("alice").SignTx(&selfTx, ctx.ChainID)
+	badTo := transfer("alice", "bob", 10, 1, 0)
+	badTo.To = "not-an-address"
+
+	cases := []struct {
+		name string
+		tx   Transaction
+		rule string
+		want error
+	}{
+		{"valid", transfer("alice", "bob", 10, 1, 0), "", nil},
+		{"malformed", badTo, "syntax", ErrMalformedTx},
+		{"mint", Transaction{To: addr("bob"), Amount: 10}, "syntax", ErrMalformedTx},
+		{"self transfer", selfTx, "self-transfer", ErrSelfTransfer},
+		{"zero amount", transfer("alice", "bob", 0, 1, 0), "amount", ErrInvalidAmount},
+		{"above supply", transfer("alice", "bob", 5000, 1, 0), "amount", ErrInvalidAmount},
+		{"low fee", transfer("alice", "bob", 10, 0, 0), "fee", ErrFeeTooLow},
+		{"future nonce", transfer("alice", "bob", 10, 1, 3), "nonce", ErrNonceTooHigh},
+		{"broke", transfer("alice", "bob", 100, 1, 0), "balance", ErrInsufficientBalance},
+		{"broke sender", transfer("carol", "bob", 10, 1, 0), "balance", ErrInsufficientBalance},
+	}
+	for _, c := range cases {
+		err := ValidateTx(c.tx, ctx, DefaultRules)
+		if c.want == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", c.name, err)
+			}
+			continue
+		}
+		var txErr *TxError
+		if !errors.As(err, &txErr) || !errors.Is(err, c.want) || txErr.Rule != c.rule {
+			t.Errorf("%s: expected %q/%v, got %v", c.name, c.rule, c.want, err)
+		}
+	}
+
+	// Self transfers are a chain parameter.
+	ctx.AllowSelfTransfer = true
+	if err := ValidateTx(selfTx, ctx, DefaultRules); err != nil {
+		t.Fatalf("self transfer rejected although allowed: %v", err)
+	}
+}
+
+func TestSendCoinsErrors(t *testing.T) {
+	bc := NewBlockchainWithConfig(fundedSpec(50, "alice", "carol"))
+	mp := NewMempool(bc, DefaultMempoolConfig)
+	alice := testWallet("alice")
+	bc.Wallets = append(bc.Wallets, alice)
+
+	if _, err := SendCoins(bc, mp, addr("nobody"), addr("bob"), 10, 0); !errors.Is(err, ErrUnknownWallet) {
+		t.Fatalf("expected unknown wallet, got %v", err)
+	}
+	if _, err := SendCoins(bc, mp, alice.Address, addr("bob"), 80, 0); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance, got %v", err)
+	}
+	if _, err := SendCoins(bc, mp, alice.Address, alice.Address, 10, 0); !errors.Is(err, ErrSelfTransfer) {
+		t.Fatalf("expected self transfer, got %v", err)
+	}
+	if _, err := SendCoins(bc, mp, alice.Address, addr("bob"), 10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bc.State().Balance(addr("bob")) != 10 {
+		t.Fatal("transfer not applied")
+	}
+}
@@ -0,0 +1,300 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ---------------- VERIFY CHAIN ----------------
+// VerifyChain audits the stored blocks: links, hashes, transaction roots,
+// signatures, proposers, state transitions and timestamps. In full mode it keeps
+// going after a failure and reports all of them, with the expected and
+// actual values, the fields that were most likely modified and the first
+// block that can no longer be trusted.
+
+// Checks reported by VerifyChain.
+const (
+	CheckGenesis     = "genesis"
+	CheckLink        = "link"
+	CheckHash        = "hash"
+	CheckTxRoot      = "tx_root"
+	CheckTransaction = "transaction"
+	CheckStateRoot   = "state_root"
+	CheckTimestamp   = "timestamp"
+	CheckSignature   = "signature"
+	CheckProposer    = "proposer"
+)
+
+// VerifyFailure is one failed check.
+type VerifyFailure struct {
+	Height   int    `json:"height"`
+	Check    string `json:"check"`
+	Field    string `json:"field,omitempty"` // e.g. "PrevHash" or "Txs[2]"
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Message  string `json:"message"`
+	// Inherited marks a failure that follows only from an earlier block
+	// having changed the state.
+	Inherited bool `json:"inherited,omitempty"`
+}
+
+// ModifiedBlock names the fields of a block that were changed after it
+// was created, as far as the failures tell.
+type ModifiedBlock struct {
+	Height int      `json:"height"`
+	Hash   string   `json:"hash"`
+	Fields []string `json:"fields"`
+}
+
+// VerifyReport is the outcome of VerifyChain.
+type VerifyReport struct {
+	Blocks         int             `json:"blocks"` // blocks checked
+	Valid          bool            `json:"valid"`
+	FirstUntrusted int             `json:"first_untrusted"` // -1 when valid
+	Failures       []VerifyFailure `json:"failures"`
+	Modified       []ModifiedBlock `json:"modified"`
+}
+
+// blockCheck collects the failures of one block.
+type blockCheck struct {
+	block    Block
+	failures []VerifyFailure
+	fields   []string
+}
+
+func (c *blockCheck) fail(check, field, expected, actual, format string, args ...interface{}) *VerifyFailure {
+	c.failures = append(c.failures, VerifyFailure{
+		Height: c.block.Index, Check: check, Field: field,
+		Expected: expected, Actual: actual, Message: fmt.Sprintf(format, args...),
+	})
+	return &c.failures[len(c.failures)-1]
+}
+
+func (c *blockCheck) modified(field string) {
+	for _, f := range c.fields {
+		if f == field {
+			return
+		}
+	}
+	c.fields = append(c.fields, field)
+}
+
+// VerifyChain checks every block from genesis. Unless full is set it stops
+// after the first block that fails.
+func (bc *Blockchain) VerifyChain(full bool) *VerifyReport {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	report := &VerifyReport{FirstUntrusted: -1, Failures: []VerifyFailure{}, Modified: []ModifiedBlock{}}
+	checks := make([]*blockCheck, 0, len(bc.Blocks))
+	state := bc.config.GenesisState()
+	diverged := false // the computed state already differs from the recorded one
+	now := bc.clock.Now()
+	for i, block := range bc.Blocks {
+		c := &blockCheck{block: block}
+		checks = append(checks, c)
+		if i == 0 {
+			bc.verifyGenesis(c)
+		} else {
+			diverged = bc.verifyBlock(c, bc.recentBlocks(i-1), checks[i-1], state, diverged, now)
+		}
+		report.Blocks++
+		if len(c.failures) > 0 && !full {
+			break
+		}
+	}
+
+	for _, c := range checks {
+		report.Failures = append(report.Failures, c.failures...)
+		if len(c.fields) > 0 {
+			report.Modified = append(report.Modified, ModifiedBlock{Height: c.block.Index, Hash: c.block.Hash, Fields: c.fields})
+		}
+		if report.FirstUntrusted < 0 && (len(c.failures) > 0 || len(c.fields) > 0) {
+			report.FirstUntrusted = c.block.Index
+		}
+	}
+	report.Valid = len(report.Failures) == 0
+	return report
+}
+
+// verifyGenesis compares the genesis block with the one the chain spec
+// produces, field by field.
+func (bc *Blockchain) verifyGenesis(c *blockCheck) {
+	want, got := NewGenesisBlock(bc.config), c.block
+	for _, f := range []struct{ name, want, got string }{
+		{"Index", fmt.Sprint(want.Index), fmt.Sprint(got.Index)},
+		{"Timestamp", want.Timestamp.String(), got.Timestamp.String()},
+		{"Data", want.Data, got.Data},
+		{"TxRoot", want.TxRoot, got.TxRoot},
+		{"Proposer", want.Proposer, got.Proposer},
+		{"PrevHash", want.PrevHash, got.PrevHash},
+		{"StateRoot", want.StateRoot, got.StateRoot},
+		{"Hash", want.Hash, got.Hash},
+	} {
+		if f.want != f.got {
+			c.fail(CheckGenesis, f.name, f.want, f.got, "genesis %s does not match the chain spec", f.name)
+			c.modified(f.name)
+		}
+	}
+}
+
+// verifyBlock checks block against the blocks before it, which end with
+// its parent, and advances state. It returns whether the computed state
+// has diverged from the recorded one.
+func (bc *Blockchain) verifyBlock(c *blockCheck, recent []Block, pc *blockCheck, state *State, diverged bool, now time.Time) bool {
+	block, parent := c.block, recent[len(recent)-1]
+	hash := CalculateHash(block)
+	hashOK := hash == block.Hash
+
+	if block.Index != parent.Index+1 {
+		c.fail(CheckLink, "Index", fmt.Sprint(parent.Index+1), fmt.Sprint(block.Index), "block index out of sequence")
+		c.modified("Index")
+	}
+	if block.PrevHash != parent.Hash {
+		c.fail(CheckLink, "PrevHash", parent.Hash, block.PrevHash, "block does not point at its parent")
+		if hashOK {
+			// This header is intact, so the parent was rewritten and rehashed.
+			pc.modified("Hash")
+		} else {
+			c.modified("PrevHash")
+		}
+	}
+	if !hashOK {
+		c.fail(CheckHash, "Hash", hash, block.Hash, "header changed after the block was hashed")
+	}
+	if err := bc.checkSignature(block); err != nil {
+		c.fail(CheckSignature, "Signature", "", block.Signature, "%v", err)
+	}
+	if err := bc.checkSlot(state, block); err != nil {
+		c.fail(CheckProposer, "Proposer", "", block.Proposer, "%v", err)
+	}
+
+	txRootOK := TxRoot(block.Txs) == block.TxRoot
+	if !txRootOK {
+		c.fail(CheckTxRoot, "TxRoot", TxRoot(block.Txs), block.TxRoot, "transactions do not match the header")
+	}
+
+	// Apply transaction by transaction so every bad one is reported.
+	ctx := bc.ruleContext(state)
+	badTxs := false
+	for i, tx := range block.Txs {
+		field := fmt.Sprintf("Txs[%d]", i)
+		if err := ValidateTx(tx, ctx, StatelessRules); err != nil {
+			c.fail(CheckTransaction, field, "", "", "%v", err)
+			c.modified(field)
+			badTxs = true
+			continue
+		}
+		if err := state.ApplyTx(tx); err != nil {
+			f := c.fail(CheckTransaction, field, "", "", "%v", err)
+			// A nonce or balance off after the state diverged says
+			// nothing about this transaction.
+			if f.Inherited = diverged; !diverged {
+				c.modified(field)
+				badTxs = true
+			}
+		}
+	}
+	for _, hook := range bc.hooks {
+		if err := hook(state, block); err != nil {
+			c.fail(CheckTransaction, "", "", "", "end of block: %v", err)
+		}
+	}
+	if !txRootOK && !badTxs {
+		if hashOK {
+			c.modified("Txs")
+		} else {
+			c.modified("TxRoot")
+		}
+	}
+
+	if root := state.Root(); root != block.StateRoot {
+		f := c.fail(CheckStateRoot, "StateRoot", root, block.StateRoot, "recorded state differs from the computed one")
+		f.Inherited = diverged && txRootOK && !badTxs
+		switch {
+		case f.Inherited:
+		case !hashOK && txRootOK && !badTxs:
+			c.modified("StateRoot")
+		case txRootOK && !badTxs:
+			// The header and transactions are intact, yet they produce
+			// another state: the transactions were changed and rehashed.
+			c.modified("Txs")
+		}
+		diverged = true
+	} else {
+		diverged = false
+	}
+
+	bc.verifyTimestamp(c, recent, now)
+	if !hashOK && len(c.fields) == 0 {
+		c.modified("Data, Proposer or Timestamp")
+	}
+	return diverged
+}
+
+// verifyTimestamp applies the timestamp rules ImportBlock enforces.
+func (bc *Blockchain) verifyTimestamp(c *blockCheck, recent []Block, now time.Time) {
+	var tsErr *TimestampError
+	if !errors.As(bc.checkTimestamp(recent, c.block, now), &tsErr) {
+		return
+	}
+	bound := tsErr.Bound.Format(time.RFC3339)
+	expected := map[error]string{
+		ErrTimestampTooOld: "after " + bound,
+		ErrTimestampFuture: "not after " + bound,
+		ErrTimestampSlot:   "on a slot boundary, such as " + bound,
+	}[tsErr.Err]
+	c.fail(CheckTimestamp, "Timestamp", expected, c.block.Timestamp.String(), "%v", tsErr.Err)
+	c.modified("Timestamp")
+}
+
+// ---------------- REPORT OUTPUT ----------------
+
+// WriteText writes the report diff-style: "-" lines hold the expected
+// value, "+" lines the value found in the chain.
+func (r *VerifyReport) WriteText(w io.Writer) {
+	if r.Valid {
+		fmt.Fprintf(w, "✅ Blockchain is valid and secure (%d blocks checked).\n", r.Blocks)
+		return
+	}
+	fmt.Fprintf(w, "❌ %d problem(s) in %d blocks checked\n", len(r.Failures), r.Blocks)
+	inherited := 0
+	for _, f := range r.Failures {
+		if f.Inherited {
+			inherited++
+			continue
+		}
+		field := ""
+		if f.Field != "" {
+			field = " " + f.Field
+		}
+		fmt.Fprintf(w, "\nblock %d: %s%s: %s\n", f.Height, f.Check, field, f.Message)
+		if f.Expected != "" {
+			fmt.Fprintf(w, "  - %s\n", f.Expected)
+		}
+		if f.Actual != "" {
+			fmt.Fprintf(w, "  + %s\n", f.Actual)
+		}
+	}
+	if inherited > 0 {
+		fmt.Fprintf(w, "\n%d later failure(s) follow only from the changes above.\n", inherited)
+	}
+	if len(r.Modified) > 0 {
+		fmt.Fprintln(w, "\nModified:")
+		for _, m := range r.Modified {
+			fmt.Fprintf(w, "  block %d (%.12s): %s\n", m.Height, m.Hash, strings.Join(m.Fields, ", "))
+		}
+	}
+	fmt.Fprintf(w, "\nFirst untrusted block: %d\n", r.FirstUntrusted)
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *VerifyReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
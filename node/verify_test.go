@@ -0,0 +1,63 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVerifyChainFullReport(t *testing.T) {
+	bc := newTestChain(t, DefaultChainOptions, 6)
+	if r := bc.VerifyChain(true); !r.Valid || r.FirstUntrusted != -1 || r.Blocks != 7 {
+		t.Fatalf("untouched chain: %+v", r)
+	}
+
+	// Raise a signed amount in block 2, and rewrite and rehash block 4.
+	bc.Blocks[2].Txs[0].Amount = 500
+	bc.Blocks[4].Data = "rewritten"
+	bc.Blocks[4].Hash = CalculateHash(bc.Blocks[4])
+
+	quick := bc.VerifyChain(false)
+	if quick.Valid || quick.Blocks != 3 || quick.FirstUntrusted != 2 {
+		t.Fatalf("quick check should stop at block 2: %+v", quick)
+	}
+
+	r := bc.VerifyChain(true)
+	if r.Valid || r.Blocks != 7 || r.FirstUntrusted != 2 {
+		t.Fatalf("full report: %+v", r)
+	}
+	found := map[string]VerifyFailure{}
+	for _, f := range r.Failures {
+		found[f.Check+" "+f.Field] = f
+	}
+	if f, ok := found["tx_root TxRoot"]; !ok || f.Height != 2 || f.Expected == f.Actual {
+		t.Fatalf("missing tx root failure: %+v", r.Failures)
+	}
+	if f, ok := found["link PrevHash"]; !ok || f.Height != 5 || f.Expected != bc.Blocks[4].Hash || f.Actual != bc.Blocks[5].PrevHash {
+		t.Fatalf("missing link failure: %+v", r.Failures)
+	}
+	modified := map[int]string{}
+	for _, m := range r.Modified {
+		modified[m.Height] = strings.Join(m.Fields, ",")
+	}
+	if modified[2] != "Txs[0]" || modified[4] != "Hash" || len(modified) != 2 {
+		t.Fatalf("modified: %v", modified)
+	}
+	for _, f := range r.Failures {
+		if f.Height == 3 && !f.Inherited {
+			t.Fatalf("block 3 only inherits the changed state: %+v", f)
+		}
+	}
+
+	var text bytes.Buffer
+	r.WriteText(&text)
+	if !strings.Contains(text.String(), "First untrusted block: 2") || !strings.Contains(text.String(), "  + "+bc.Blocks[2].TxRoot) {
+		t.Fatalf("text report:\n%s", text.String())
+	}
+	var buf bytes.Buffer
+	var decoded VerifyReport
+	if err := r.WriteJSON(&buf); err != nil || json.Unmarshal(buf.Bytes(), &decoded) != nil || len(decoded.Failures) != len(r.Failures) {
+		t.Fatalf("JSON report: %v\n%s", err, buf.String())
+	}
+}
@@ -1,40 +1,134 @@
 package node
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 )
 
 // ---------------- WALLET STRUCT ----------------
+// Balances are not stored here: they live in the chain state. Keys are
+// ed25519, whose signatures are deterministic, so the same transaction
+// always hashes the same way.
 type Wallet struct {
-	Address string `json:"Address"`
-	Balance int    `json:"Balance"`
+	Address    string `json:"Address"`
+	PublicKey  string `json:"PublicKey"`
+	PrivateKey string `json:"PrivateKey"`
 }
 
 // ---------------- CREATE NEW WALLET ----------------
-func NewWallet(initialBalance int) *Wallet {
-	addr := generateAddress()
+// NewWallet creates a wallet. A positive initialBalance is paid to it by a
+// signed transfer from a local wallet that holds genesis funds, queued in
+// the mempool and confirmed in a block produced from it, as SendCoins
+// does: no coins are created after genesis.
+func NewWallet(bc *Blockchain, mp *Mempool, initialBalance int) (*Wallet, error) {
+	wallet, err := GenerateWallet()
+	if err != nil {
+		return nil, err
+	}
+	if initialBalance > 0 {
+		cfg := bc.Config()
+		funder := genesisFunder(bc, initialBalance+cfg.MinTxFee)
+		if funder == nil {
+			return nil, fmt.Errorf("%w: no local genesis account holds %d", ErrInsufficientBalance, initialBalance+cfg.MinTxFee)
+		}
+		tx := Transaction{To: wallet.Address, Amount: initialBalance, Fee: cfg.MinTxFee, Nonce: mp.NextNonce(funder.Address)}
+		if err := funder.SignTx(&tx, cfg.ChainID); err != nil {
+			return nil, err
+		}
+		if err := mp.Add(tx); err != nil {
+			return nil, err
+		}
+		data := fmt.Sprintf("Funded %s with %d ProCo from %s", wallet.Address, initialBalance, funder.Address)
+		if _, err := bc.ProduceBlock(mp, data); err != nil {
+			return nil, err
+		}
+	}
+	return wallet, nil
+}
+
+// genesisFunder returns the first local wallet that is in the genesis
+// alloc and still holds at least amount, or nil.
+func genesisFunder(bc *Blockchain, amount int) *Wallet {
+	state := bc.State()
+	for _, a := range bc.Config().Alloc {
+		if w := FindWallet(bc, a.Address); w != nil && state.Balance(w.Address) >= amount {
+			return w
+		}
+	}
+	return nil
+}
+
+// ---------------- GENERATE KEYS ----------------
+// GenerateWallet creates a wallet with a fresh random key pair.
+func GenerateWallet() (*Wallet, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return WalletFromSeed(seed), nil
+}
+
+// DevWallet is the development account that DefaultConfig funds. Its key
+// comes from a published phrase, so anyone can spend its coins: it is for
+// local chains only.
+func DevWallet() *Wallet {
+	seed := sha256.Sum256([]byte("proco-node dev account"))
+	return WalletFromSeed(seed[:])
+}
+
+// WalletFromSeed derives a wallet from a 32-byte ed25519 seed.
+func WalletFromSeed(seed []byte) *Wallet {
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
 	return &Wallet{
-		Address: addr,
-		Balance: initialBalance,
+		Address:    AddressFromPublicKey(pub),
+		PublicKey:  hex.EncodeToString(pub),
+		PrivateKey: hex.EncodeToString(seed),
 	}
 }
 
-// ---------------- GENERATE RANDOM ADDRESS ----------------
-func generateAddress() string {
-	bytes := make([]byte, 16)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		panic(err)
+// AddressFromPublicKey is the first 16 bytes of the key's SHA-256, in hex.
+func AddressFromPublicKey(pub ed25519.PublicKey) string {
+	h := sha256.Sum256(pub)
+	return hex.EncodeToString(h[:16])
+}
+
+// ---------------- SIGN TRANSACTION ----------------
+// SignTx fills in the sender, chain ID and public key of tx and signs it.
+func (w *Wallet) SignTx(tx *Transaction, chainID string) error {
+	seed, err := hex.DecodeString(w.PrivateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("wallet has no usable private key")
 	}
-	return hex.EncodeToString(bytes)
+	tx.From = w.Address
+	tx.ChainID = chainID
+	tx.PubKey = w.PublicKey
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed), tx.SigningPayload())
+	tx.Signature = hex.EncodeToString(sig)
+	return nil
+}
+
+// SignBlock signs the hash of a block proposed with this wallet's key.
+func (w *Wallet) SignBlock(block *Block) error {
+	seed, err := hex.DecodeString(w.PrivateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("wallet has no usable private key")
+	}
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed), blockSigningPayload(block.Hash))
+	block.Signature = hex.EncodeToString(sig)
+	return nil
 }
 
 // ---------------- FIND WALLET ----------------
 func FindWallet(bc *Blockchain, address string) *Wallet {
+	bc.walletsMu.RLock()
+	defer bc.walletsMu.RUnlock()
 	for _, w := range bc.Wallets {
 		if w.Address == address {
 			return w
@@ -44,52 +138,84 @@ func FindWallet(bc *Blockchain, address string) *Wallet {
 }
 
 // ---------------- SEND COINS ----------------
-func SendCoins(bc *Blockchain, fromAddr, toAddr string, amount int) bool {
+// SendCoins queues a transfer in the mempool and then produces a block
+// from the mempool, so the transfer is normally confirmed at once. The
+// sender must be a local wallet; the receiver may be any address.
+func SendCoins(bc *Blockchain, mp *Mempool, fromAddr, toAddr string, amount, fee int) (Block, error) {
 	fromWallet := FindWallet(bc, fromAddr)
-	toWallet := FindWallet(bc, toAddr)
-
-	if fromWallet == nil || toWallet == nil {
-		fmt.Println("❌ Wallet not found")
-		return false
+	if fromWallet == nil {
+		return Block{}, fmt.Errorf("%w: %s", ErrUnknownWallet, fromAddr)
 	}
 
-	if fromWallet.Balance < amount {
-		fmt.Println("❌ Insufficient balance")
-		return false
+	tx := Transaction{To: toAddr, Amount: amount, Fee: fee, Nonce: mp.NextNonce(fromAddr)}
+	if err := fromWallet.SignTx(&tx, bc.Config().ChainID); err != nil {
+		return Block{}, err
+	}
+	if err := mp.Add(tx); err != nil {
+		return Block{}, err
 	}
-
-	fromWallet.Balance -= amount
-	toWallet.Balance += amount
 
 	// --- Automatic block creation ---
 	txData := fmt.Sprintf("Sent %d ProCo from %s to %s", amount, fromAddr, toAddr)
-	bc.AddBlock(txData)
-	fmt.Println("✅ Transaction successful and saved to blockchain.")
+	return bc.ProduceBlock(mp, txData)
+}
 
-	// Save wallets
-	err := bc.SaveWallets("wallets.json")
-	if err != nil {
-		fmt.Println("Error saving wallets:", err)
-	}
+// ---------------- LOCAL WALLETS ----------------
+// LocalWallets returns the local wallets in the order they were added.
+func (bc *Blockchain) LocalWallets() []*Wallet {
+	bc.walletsMu.RLock()
+	defer bc.walletsMu.RUnlock()
+	return append([]*Wallet(nil), bc.Wallets...)
+}
 
-	return true
+// AddWallet adds w to the local wallets and saves them where LoadWallets
+// found them.
+func (bc *Blockchain) AddWallet(w *Wallet) error {
+	bc.walletsMu.Lock()
+	defer bc.walletsMu.Unlock()
+	bc.Wallets = append(bc.Wallets, w)
+	return bc.saveWallets(bc.walletsFile())
 }
 
 // ---------------- SAVE WALLETS ----------------
 func (bc *Blockchain) SaveWallets(filename string) error {
-	file, err := os.Create(filename)
+	bc.walletsMu.RLock()
+	defer bc.walletsMu.RUnlock()
+	return bc.saveWallets(filename)
+}
+
+// saveWallets writes the wallets to filename. The private keys are stored
+// unencrypted, so only the owner may read or write the file: it is created
+// with mode 0600, and an existing file is narrowed to that. The caller
+// holds walletsMu.
+func (bc *Blockchain) saveWallets(filename string) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	if err := file.Chmod(0o600); err != nil {
+		return err
+	}
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(bc.Wallets)
 }
 
+// walletsFile is where the REPL keeps the local wallets.
+func (bc *Blockchain) walletsFile() string {
+	if bc.walletsPath == "" {
+		return WalletsFile
+	}
+	return bc.walletsPath
+}
+
 // ---------------- LOAD WALLETS ----------------
 func (bc *Blockchain) LoadWallets(filename string) error {
+	bc.walletsMu.Lock()
+	defer bc.walletsMu.Unlock()
+	bc.walletsPath = filename
 	file, err := os.Open(filename)
 	if err != nil {
 		bc.Wallets = []*Wallet{}
@@ -109,10 +235,5 @@ func (bc *Blockchain) LoadWallets(filename string) error {
 
 // ---------------- GET BALANCE ----------------
 func GetBalance(bc *Blockchain, address string) int {
-	for _, w := range bc.Wallets {
-		if w.Address == address {
-			return w.Balance
-		}
-	}
-	return 0
+	return bc.State().Balance(address)
 }
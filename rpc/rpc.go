@@ -0,0 +1,406 @@
+// Package rpc exposes a running node over JSON-RPC 2.0 on HTTP.
+//
+// Methods are named <namespace>_<method>, for example account_getProof.
+// Params are positional, as a JSON array.
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"proco-node/explorer"
+	"proco-node/node"
+)
+
+// DefaultAddr is where a node serves RPC when no address is given.
+const DefaultAddr = "127.0.0.1:8545"
+
+var log = node.Logger(node.LogRPC)
+
+// ---------------- WIRE FORMAT ----------------
+type Request struct {
+	JSONRPC string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+	ID      interface{}       `json:"id"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      interface{}     `json:"id"`
+}
+
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeServerError    = -32000
+	CodeTxRejected     = -32003 // data is the name of the failed rule
+	CodeNotFound       = -32004
+)
+
+var errNoNetwork = &Error{Code: CodeServerError, Message: "networking is not enabled on this node"}
+
+// ---------------- SERVER ----------------
+type handler func(params []json.RawMessage) (interface{}, error)
+
+type Server struct {
+	bc       *node.Blockchain
+	mp       *node.Mempool
+	network  *node.Network // nil without p2p
+	console  *node.Console
+	explorer *explorer.Explorer
+	methods  map[string]handler
+}
+
+// NewServer serves the given node. network may be nil.
+func NewServer(bc *node.Blockchain, mp *node.Mempool, network *node.Network) *Server {
+	s := &Server{bc: bc, mp: mp, network: network, console: node.NewConsole(bc, mp, network),
+		explorer: explorer.New(bc, mp), methods: make(map[string]handler)}
+	s.methods["account_getProof"] = s.accountGetProof
+	s.methods["tx_send"] = s.txSend
+	s.methods["chain_getGenesis"] = s.chainGetGenesis
+	s.methods["chain_getHeight"] = s.chainGetHeight
+	s.methods["chain_getBlock"] = s.chainGetBlock
+	s.methods["tx_get"] = s.txGet
+	s.methods["account_getHistory"] = s.accountGetHistory
+	s.methods["account_getBalance"] = s.accountGetBalance
+	s.methods["rpc_methods"] = s.rpcMethods
+	s.methods["console_exec"] = s.consoleExec
+	s.methods["console_commands"] = s.consoleCommands
+	s.methods["p2p_getPartition"] = s.p2pGetPartition
+	s.methods["p2p_setPartition"] = s.p2pSetPartition
+	s.methods["p2p_getFaults"] = s.p2pGetFaults
+	s.methods["p2p_addFault"] = s.p2pAddFault
+	s.methods["p2p_removeFault"] = s.p2pRemoveFault
+	s.methods["p2p_clearFaults"] = s.p2pClearFaults
+	return s
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	log.Info("listening", "addr", addr)
+	return http.ListenAndServe(addr, s)
+}
+
+// ServeHTTP answers JSON-RPC calls. GET requests are for the pages and
+// streams in serveGet.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.serveGet(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	resp := Response{JSONRPC: "2.0"}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.Error = &Error{Code: CodeParseError, Message: err.Error()}
+	} else {
+		resp.ID = req.ID
+		resp.Result, resp.Error = s.call(req)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) call(req Request) (json.RawMessage, *Error) {
+	m := s.bc.Metrics()
+	h, ok := s.methods[req.Method]
+	if !ok {
+		log.Debug("unknown method", "method", req.Method)
+		m.RPCErrors.With("unknown").Inc()
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	start := time.Now()
+	result, err := h(req.Params)
+	took := time.Since(start)
+	log.Debug("call", "method", req.Method, "took", took, "err", err)
+	m.RPCRequests.With(req.Method).Inc()
+	m.RPCDuration.With(req.Method).Observe(took.Seconds())
+	if err != nil {
+		m.RPCErrors.With(req.Method).Inc()
+		if rpcErr, ok := err.(*Error); ok {
+			return nil, rpcErr
+		}
+		var txErr *node.TxError
+		if errors.As(err, &txErr) {
+			return nil, &Error{Code: CodeTxRejected, Message: err.Error(), Data: txErr.Rule}
+		}
+		if errors.Is(err, node.ErrNotFound) {
+			return nil, &Error{Code: CodeNotFound, Message: err.Error()}
+		}
+		if errors.Is(err, node.ErrBadCursor) || errors.Is(err, node.ErrBadFault) {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return nil, &Error{Code: CodeServerError, Message: err.Error()}
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return nil, &Error{Code: CodeServerError, Message: err.Error()}
+	}
+	return out, nil
+}
+
+// ---------------- METHODS ----------------
+
+// account_getProof [address, height?] returns the account with a Merkle
+// proof against the state root of the block at height (default: latest).
+func (s *Server) accountGetProof(params []json.RawMessage) (interface{}, error) {
+	var address string
+	height := -1
+	if err := parseParams(params, 1, &address, &height); err != nil {
+		return nil, err
+	}
+	return s.bc.GetProof(address, height)
+}
+
+// rpc_methods [] lists the methods this server offers.
+func (s *Server) rpcMethods(params []json.RawMessage) (interface{}, error) {
+	names := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// console_exec [line] runs a console command, as typed at the node's own
+// prompt, and returns its text and value.
+func (s *Server) consoleExec(params []json.RawMessage) (interface{}, error) {
+	var line string
+	if err := parseParams(params, 1, &line); err != nil {
+		return nil, err
+	}
+	return s.console.Exec(line)
+}
+
+// console_commands [] lists the console commands with their arguments.
+func (s *Server) consoleCommands(params []json.RawMessage) (interface{}, error) {
+	return node.Commands, nil
+}
+
+// chain_getHeight [] returns the index of the last block.
+func (s *Server) chainGetHeight(params []json.RawMessage) (interface{}, error) {
+	return s.bc.Height(), nil
+}
+
+// chain_getBlock [height or hash] returns a block.
+func (s *Server) chainGetBlock(params []json.RawMessage) (interface{}, error) {
+	var id json.RawMessage
+	if err := parseParams(params, 1, &id); err != nil {
+		return nil, err
+	}
+	return s.bc.FindBlock(strings.Trim(string(id), `"`))
+}
+
+// tx_get [hash] returns an included transaction with its block.
+func (s *Server) txGet(params []json.RawMessage) (interface{}, error) {
+	var hash string
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	return s.bc.TxByHash(hash)
+}
+
+// account_getHistory [address, cursor?, limit?] returns a page of the
+// included transactions sent or received by address, newest first. The
+// page's next cursor fetches the following page.
+func (s *Server) accountGetHistory(params []json.RawMessage) (interface{}, error) {
+	var address, cursor string
+	var limit int
+	if err := parseParams(params, 1, &address, &cursor, &limit); err != nil {
+		return nil, err
+	}
+	return s.bc.AddressHistory(address, cursor, limit)
+}
+
+// account_getBalance [address] returns the account at the head of the
+// chain, with zero balance and nonce for unknown addresses.
+func (s *Server) accountGetBalance(params []json.RawMessage) (interface{}, error) {
+	var address string
+	if err := parseParams(params, 1, &address); err != nil {
+		return nil, err
+	}
+	acc := node.Account{}
+	if a := s.bc.State().Get(address); a != nil {
+		acc = *a
+	}
+	return acc, nil
+}
+
+// chain_getGenesis [] returns the chain ID and genesis block hash, so two
+// nodes can be checked for belonging to the same network.
+func (s *Server) chainGetGenesis(params []json.RawMessage) (interface{}, error) {
+	return map[string]string{
+		"chain_id": s.bc.Config().ChainID,
+		"hash":     s.bc.GenesisHash(),
+	}, nil
+}
+
+// tx_send [tx] adds a signed transaction to the mempool and returns its
+// hash. It runs the same validation rules as the REPL and gossip.
+func (s *Server) txSend(params []json.RawMessage) (interface{}, error) {
+	var tx node.Transaction
+	if err := parseParams(params, 1, &tx); err != nil {
+		return nil, err
+	}
+	if err := s.mp.Add(tx); err != nil {
+		return nil, err
+	}
+	if s.network != nil {
+		s.network.BroadcastTx(tx)
+	}
+	return tx.Hash(), nil
+}
+
+// parseParams decodes positional params into out. The first required
+// entries must be present; the rest are optional and keep their values.
+func parseParams(params []json.RawMessage, required int, out ...interface{}) error {
+	if len(params) < required || len(params) > len(out) {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("expected %d to %d params, got %d", required, len(out), len(params))}
+	}
+	for i, raw := range params {
+		if err := json.Unmarshal(raw, out[i]); err != nil {
+			return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("param %d: %v", i, err)}
+		}
+	}
+	return nil
+}
+
+// p2p_getPartition [] lists the peers this node is cut off from.
+func (s *Server) p2pGetPartition(params []json.RawMessage) (interface{}, error) {
+	if s.network == nil {
+		return nil, errNoNetwork
+	}
+	return s.network.Partition(), nil
+}
+
+// p2p_setPartition [peers] cuts this node off from peers, given as P2P
+// addresses; an empty list heals the partition.
+func (s *Server) p2pSetPartition(params []json.RawMessage) (interface{}, error) {
+	var peers []string
+	if err := parseParams(params, 1, &peers); err != nil {
+		return nil, err
+	}
+	if s.network == nil {
+		return nil, errNoNetwork
+	}
+	s.network.SetPartition(peers)
+	return s.network.Partition(), nil
+}
+
+// p2p_getFaults [] lists the fault injection rules.
+func (s *Server) p2pGetFaults(params []json.RawMessage) (interface{}, error) {
+	if s.network == nil {
+		return nil, errNoNetwork
+	}
+	return s.network.Faults(), nil
+}
+
+// p2p_addFault [rule] adds a fault rule such as {"action": "drop",
+// "type": "BLOCK", "prob": 0.5} and returns it with its id.
+func (s *Server) p2pAddFault(params []json.RawMessage) (interface{}, error) {
+	var rule node.FaultRule
+	if err := parseParams(params, 1, &rule); err != nil {
+		return nil, err
+	}
+	if s.network == nil {
+		return nil, errNoNetwork
+	}
+	return s.network.AddFault(rule)
+}
+
+// p2p_removeFault [id] removes a fault rule and lists the others.
+func (s *Server) p2pRemoveFault(params []json.RawMessage) (interface{}, error) {
+	var id int
+	if err := parseParams(params, 1, &id); err != nil {
+		return nil, err
+	}
+	if s.network == nil {
+		return nil, errNoNetwork
+	}
+	if err := s.network.RemoveFault(id); err != nil {
+		return nil, err
+	}
+	return s.network.Faults(), nil
+}
+
+// p2p_clearFaults [] removes every fault rule.
+func (s *Server) p2pClearFaults(params []json.RawMessage) (interface{}, error) {
+	if s.network == nil {
+		return nil, errNoNetwork
+	}
+	s.network.ClearFaults()
+	return s.network.Faults(), nil
+}
+
+// ---------------- CLIENT ----------------
+type Client struct {
+	url    string
+	nextID uint64
+}
+
+// Dial returns a client for the node serving RPC at url. No connection is
+// made until the first call.
+func Dial(url string) *Client {
+	return &Client{url: url}
+}
+
+// Call invokes method with positional params and decodes the result into
+// result, which may be nil.
+func (c *Client) Call(result interface{}, method string, params ...interface{}) error {
+	req := Request{JSONRPC: "2.0", Method: method, ID: atomic.AddUint64(&c.nextID, 1)}
+	for _, p := range params {
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		req.Params = append(req.Params, raw)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpResp, err := http.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	var resp Response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
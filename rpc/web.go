@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"proco-node/explorer"
+	"proco-node/node"
+)
+
+// ---------------- WEB ----------------
+// Besides JSON-RPC over POST the server answers a few GET paths:
+//
+//	/metrics       metrics in the Prometheus text format
+//	/viz           the network visualiser page
+//	/viz/status    this node's height and head, and what it heard from peers
+//	/viz/events    every message sent or received, as server-sent events
+//	/explorer/     the block explorer
+//
+// The status and events are readable from other origins, so the page
+// served by one node can follow the other nodes too.
+
+const (
+	MetricsPath   = "/metrics"
+	VizPath       = "/viz"
+	VizStatusPath = "/viz/status"
+	VizEventsPath = "/viz/events"
+)
+
+//go:embed web/viz.html
+var vizPage []byte
+
+func (s *Server) serveGet(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case MetricsPath:
+		s.bc.Metrics().Registry.Handler().ServeHTTP(w, r)
+	case "/", VizPath:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(vizPage)
+	case VizStatusPath:
+		s.serveStatus(w)
+	case VizEventsPath:
+		s.serveEvents(w, r)
+	default:
+		if strings.HasPrefix(r.URL.Path, explorer.Path) {
+			s.explorer.ServeHTTP(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter) {
+	var status node.NetworkStatus
+	if s.network != nil {
+		status = s.network.Status()
+	} else {
+		head := s.bc.Head()
+		status.Self = node.PeerStatus{Height: head.Index, Head: head.Hash, LastSeen: time.Now()}
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if s.network == nil || !ok {
+		http.Error(w, "networking is not enabled on this node", http.StatusNotFound)
+		return
+	}
+	events, stop := s.network.Watch()
+	defer stop()
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-events:
+			b, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "data: %s\n\n", b)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package testnet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// ---------------- LOG VIEW ----------------
+// LogView merges the log lines of all nodes. It keeps the latest lines
+// for Tail and can follow new ones to an output, filtered by node.
+
+// LogHistory is how many lines a LogView keeps.
+const LogHistory = 2000
+
+// LogLine is one log line and the node that wrote it.
+type LogLine struct {
+	Node string
+	Text string
+}
+
+func (l LogLine) String() string {
+	return fmt.Sprintf("%-6s | %s", l.Node, l.Text)
+}
+
+type LogView struct {
+	mu     sync.Mutex
+	lines  []LogLine
+	follow io.Writer       // nil when not following
+	only   map[string]bool // nodes to follow; empty means all
+}
+
+func NewLogView() *LogView {
+	return &LogView{}
+}
+
+// nodeAttr finds the node attribute in lines of nodes running in this
+// process, which share one logger.
+var nodeAttr = regexp.MustCompile(`\bnode=(\S+)`)
+
+// Add records a line. An empty node is taken from the line's node
+// attribute.
+func (v *LogView) Add(node, text string) {
+	if node == "" {
+		node = "-"
+		if m := nodeAttr.FindStringSubmatch(text); m != nil {
+			node = m[1]
+		}
+	}
+	line := LogLine{Node: node, Text: text}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.lines = append(v.lines, line)
+	if len(v.lines) > LogHistory {
+		v.lines = v.lines[len(v.lines)-LogHistory:]
+	}
+	if v.follow != nil && (len(v.only) == 0 || v.only[node]) {
+		fmt.Fprintf(v.follow, "%s\r\n", line)
+	}
+}
+
+// Tail returns up to n of the latest lines, only of the given nodes if
+// any are named.
+func (v *LogView) Tail(n int, nodes ...string) []LogLine {
+	only := set(nodes)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var out []LogLine
+	for i := len(v.lines) - 1; i >= 0 && len(out) < n; i-- {
+		if len(only) == 0 || only[v.lines[i].Node] {
+			out = append(out, v.lines[i])
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// Follow writes new lines to w as they arrive, only those of the given
+// nodes if any are named. A nil w stops following.
+func (v *LogView) Follow(w io.Writer, nodes ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.follow, v.only = w, set(nodes)
+}
+
+// Writer returns a writer that adds every line written to it, as written
+// by node. An empty node is taken from each line.
+func (v *LogView) Writer(node string) io.Writer {
+	return &lineWriter{view: v, node: node}
+}
+
+type lineWriter struct {
+	mu   sync.Mutex
+	view *LogView
+	node string
+	buf  []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.view.Add(w.node, string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+func set(names []string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
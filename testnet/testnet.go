@@ -0,0 +1,442 @@
+package testnet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"proco-node/node"
+	"proco-node/rpc"
+)
+
+// ---------------- TESTNET ----------------
+// A testnet is a local network of full nodes on loopback ports, generated
+// by node.InitNetwork with one shared genesis. The nodes run inside this
+// process or as child processes of it. Either way they are controlled over
+// their RPC APIs, so stopping, restarting and partitioning work the same.
+
+// StartTimeout is how long a node may take to answer RPC after starting.
+const StartTimeout = 10 * time.Second
+
+// Options describes a testnet.
+type Options struct {
+	Dir     string // network directory; one that already holds a network is reused
+	Nodes   int
+	ChainID string
+	Alloc   map[string]int // genesis balances by node name or address
+	Host    string
+	P2PPort int // of node1, the others count up
+	RPCPort int
+
+	// Processes runs every node as `<Binary> run --daemon` instead of in
+	// this process. Binary defaults to the running executable.
+	Processes bool
+	Binary    string
+	LogLevel  string // passed to the nodes, e.g. "info,p2p=debug"
+}
+
+// Testnet is a running local network.
+type Testnet struct {
+	opts  Options
+	Spec  *node.Config
+	Logs  *LogView
+	mu    sync.Mutex
+	nodes []*member
+	cut   map[string][]string // partition: node name -> names it is cut off from
+}
+
+type member struct {
+	name   string
+	cfg    *node.NodeConfig
+	client *rpc.Client
+
+	// in this process
+	node   *node.Node
+	server *http.Server
+
+	// as a child process
+	proc *exec.Cmd
+	done chan struct{} // closed when the process exits
+}
+
+func (m *member) running() bool {
+	if m.proc != nil {
+		select {
+		case <-m.done:
+			return false
+		default:
+			return true
+		}
+	}
+	return m.node != nil
+}
+
+// NodeStatus is the state of one node.
+type NodeStatus struct {
+	Name    string   `json:"name"`
+	Running bool     `json:"running"`
+	P2PAddr string   `json:"p2p_addr"`
+	RPCAddr string   `json:"rpc_addr"`
+	Height  int      `json:"height"`
+	Head    string   `json:"head,omitempty"`
+	Cut     []string `json:"cut,omitempty"` // nodes it is partitioned from
+}
+
+// New writes the network to opts.Dir unless it is already there, and
+// prepares its nodes without starting them.
+func New(opts Options) (*Testnet, error) {
+	if opts.Host == "" {
+		opts.Host = "127.0.0.1"
+	}
+	if opts.Processes && opts.Binary == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		opts.Binary = exe
+	}
+	spec, err := node.LoadConfig(filepath.Join(opts.Dir, node.GenesisFile))
+	if errors.Is(err, os.ErrNotExist) {
+		spec, err = node.InitNetwork(node.InitOptions{
+			Dir:        opts.Dir,
+			ChainID:    opts.ChainID,
+			Validators: opts.Nodes,
+			Alloc:      opts.Alloc,
+			Host:       opts.Host,
+			P2PPort:    opts.P2PPort,
+			RPCPort:    opts.RPCPort,
+			MinTxFee:   1,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Nodes != 0 && opts.Nodes != len(spec.Validators) {
+		return nil, fmt.Errorf("%s holds a network of %d nodes, not %d", opts.Dir, len(spec.Validators), opts.Nodes)
+	}
+
+	t := &Testnet{opts: opts, Spec: spec, Logs: NewLogView(), cut: map[string][]string{}}
+	for _, v := range spec.Validators {
+		cfg, err := node.LoadNodeConfig(filepath.Join(opts.Dir, v.Name))
+		if err != nil {
+			return nil, err
+		}
+		t.nodes = append(t.nodes, &member{name: v.Name, cfg: cfg, client: rpc.Dial("http://" + cfg.RPCAddr)})
+	}
+	if !opts.Processes {
+		level, levels, err := node.ParseLogLevels(opts.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		node.SetupLogging(node.LogConfig{Level: level, Levels: levels, Output: t.Logs.Writer("")})
+	}
+	return t, nil
+}
+
+// Names lists the nodes in order.
+func (t *Testnet) Names() []string {
+	names := make([]string, len(t.nodes))
+	for i, m := range t.nodes {
+		names[i] = m.name
+	}
+	return names
+}
+
+// Resolve turns "3" or "node3" into a node name.
+func (t *Testnet) Resolve(ref string) (string, error) {
+	if i, err := strconv.Atoi(ref); err == nil && i >= 1 && i <= len(t.nodes) {
+		return t.nodes[i-1].name, nil
+	}
+	for _, m := range t.nodes {
+		if m.name == ref {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("no node %q (have %s)", ref, strings.Join(t.Names(), ", "))
+}
+
+func (t *Testnet) member(name string) (*member, error) {
+	name, err := t.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range t.nodes {
+		if m.name == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no node %q", name)
+}
+
+// ---------------- START AND STOP ----------------
+
+// Start starts every node that is not running.
+func (t *Testnet) Start() error {
+	for _, name := range t.Names() {
+		if err := t.StartNode(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartNode starts one node and waits until it answers RPC. A partition
+// set before applies to it again.
+func (t *Testnet) StartNode(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m, err := t.member(name)
+	if err != nil {
+		return err
+	}
+	if m.running() {
+		return fmt.Errorf("%s is already running", m.name)
+	}
+	if t.opts.Processes {
+		err = t.spawn(m)
+	} else {
+		err = t.open(m)
+	}
+	if err != nil {
+		return fmt.Errorf("starting %s: %w", m.name, err)
+	}
+	if err := waitForRPC(m.client); err != nil {
+		return fmt.Errorf("starting %s: %w", m.name, err)
+	}
+	t.Logs.Add(m.name, "testnet: started")
+	return t.applyPartition(m)
+}
+
+// open runs the node in this process.
+func (t *Testnet) open(m *member) error {
+	n, err := node.OpenNode(m.cfg)
+	if err != nil {
+		return err
+	}
+	n.Chain.SetLogName(m.name)
+	n.Network.SetRPCAddr(m.cfg.RPCAddr)
+	ln, err := net.Listen("tcp", m.cfg.RPCAddr)
+	if err != nil {
+		n.Mempool.CloseJournal()
+		return err
+	}
+	if err := n.Start(); err != nil {
+		ln.Close()
+		n.Mempool.CloseJournal()
+		return err
+	}
+	m.node = n
+	m.server = &http.Server{Handler: rpc.NewServer(n.Chain, n.Mempool, n.Network)}
+	go m.server.Serve(ln)
+	return nil
+}
+
+// spawn runs the node as a child process, its output going to the logs.
+func (t *Testnet) spawn(m *member) error {
+	args := []string{"run", "--daemon", "--datadir", m.cfg.DataDir}
+	if t.opts.LogLevel != "" {
+		args = append(args, "--log-level", t.opts.LogLevel)
+	}
+	cmd := exec.Command(t.opts.Binary, args...)
+	cmd.Stdout = t.Logs.Writer(m.name)
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	m.proc, m.done = cmd, make(chan struct{})
+	go func(done chan struct{}) {
+		err := cmd.Wait()
+		t.Logs.Add(m.name, fmt.Sprintf("testnet: process exited (%v)", exitStatus(err)))
+		close(done)
+	}(m.done)
+	return nil
+}
+
+func exitStatus(err error) string {
+	if err == nil {
+		return "status 0"
+	}
+	return err.Error()
+}
+
+// StopNode stops one node. Its data directory is kept.
+func (t *Testnet) StopNode(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m, err := t.member(name)
+	if err != nil {
+		return err
+	}
+	if !m.running() {
+		return fmt.Errorf("%s is not running", m.name)
+	}
+	t.stop(m)
+	t.Logs.Add(m.name, "testnet: stopped")
+	return nil
+}
+
+func (t *Testnet) stop(m *member) {
+	if m.proc != nil {
+		m.proc.Process.Signal(os.Interrupt)
+		select {
+		case <-m.done:
+		case <-time.After(5 * time.Second):
+			m.proc.Process.Kill()
+			<-m.done
+		}
+		m.proc = nil
+		return
+	}
+	m.server.Close()
+	m.node.Stop()
+	m.node, m.server = nil, nil
+}
+
+// RestartNode stops a running node and starts it again.
+func (t *Testnet) RestartNode(name string) error {
+	if err := t.StopNode(name); err != nil {
+		return err
+	}
+	return t.StartNode(name)
+}
+
+// Close stops every running node.
+func (t *Testnet) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, m := range t.nodes {
+		if m.running() {
+			t.stop(m)
+		}
+	}
+}
+
+func waitForRPC(c *rpc.Client) error {
+	var height int
+	var err error
+	for deadline := time.Now().Add(StartTimeout); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if err = c.Call(&height, "chain_getHeight"); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no RPC answer: %w", err)
+}
+
+// ---------------- PARTITIONS ----------------
+
+// Partition splits the network into groups of node names; nodes in no
+// group form one more group. Nodes only talk within their group.
+func (t *Testnet) Partition(groups [][]string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	group := map[string]int{}
+	for i, g := range groups {
+		for _, ref := range g {
+			name, err := t.Resolve(ref)
+			if err != nil {
+				return err
+			}
+			if _, dup := group[name]; dup {
+				return fmt.Errorf("%s is in two groups", name)
+			}
+			group[name] = i + 1
+		}
+	}
+	cut := map[string][]string{}
+	for _, a := range t.nodes {
+		for _, b := range t.nodes {
+			if group[a.name] != group[b.name] {
+				cut[a.name] = append(cut[a.name], b.name)
+			}
+		}
+	}
+	t.cut = cut
+	return t.applyPartitions()
+}
+
+// Heal removes the partition.
+func (t *Testnet) Heal() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cut = map[string][]string{}
+	return t.applyPartitions()
+}
+
+func (t *Testnet) applyPartitions() error {
+	var errs []error
+	for _, m := range t.nodes {
+		if m.running() {
+			errs = append(errs, t.applyPartition(m))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyPartition tells m which peers it is cut off from.
+func (t *Testnet) applyPartition(m *member) error {
+	peers := []string{}
+	for _, name := range t.cut[m.name] {
+		other, _ := t.member(name)
+		peers = append(peers, other.cfg.P2PAddr)
+	}
+	if err := m.client.Call(nil, "p2p_setPartition", peers); err != nil {
+		return fmt.Errorf("%s: %w", m.name, err)
+	}
+	return nil
+}
+
+// ---------------- STATUS AND COMMANDS ----------------
+
+// Status reports every node.
+func (t *Testnet) Status() []NodeStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]NodeStatus, len(t.nodes))
+	for i, m := range t.nodes {
+		st := NodeStatus{Name: m.name, Running: m.running(), P2PAddr: m.cfg.P2PAddr, RPCAddr: m.cfg.RPCAddr, Cut: t.cut[m.name]}
+		if st.Running {
+			var head node.Block
+			if m.client.Call(&st.Height, "chain_getHeight") == nil &&
+				m.client.Call(&head, "chain_getBlock", strconv.Itoa(st.Height)) == nil {
+				st.Head = head.Hash
+			}
+		}
+		out[i] = st
+	}
+	return out
+}
+
+// Exec runs a console command on a node.
+func (t *Testnet) Exec(name, line string) (*node.CommandResult, error) {
+	m, err := t.member(name)
+	if err != nil {
+		return nil, err
+	}
+	var res node.CommandResult
+	if err := m.client.Call(&res, "console_exec", line); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Fault runs the fault console command with args on a node. Peers may be
+// given by node name, as in "add drop peer=node2".
+func (t *Testnet) Fault(name string, args []string) (*node.CommandResult, error) {
+	args = append([]string(nil), args...)
+	for i, a := range args {
+		if ref, ok := strings.CutPrefix(a, "peer="); ok {
+			if other, err := t.member(ref); err == nil {
+				args[i] = "peer=" + other.cfg.P2PAddr
+			}
+		}
+	}
+	return t.Exec(name, "fault "+strings.Join(args, " "))
+}